@@ -33,3 +33,11 @@ func GetConfigString(key string) string {
 func GetConfigInt(key string) int {
 	return viperInstance.GetInt(key)
 }
+
+func GetConfigStringMap(key string) map[string]string {
+	return viperInstance.GetStringMapString(key)
+}
+
+func IsConfigSet(key string) bool {
+	return viperInstance.IsSet(key)
+}
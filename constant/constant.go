@@ -6,3 +6,5 @@ var (
 	ParseQueryParamErr = "error parsing query param"
 	ValidationErr      = "validation error"
 )
+
+const DateFormat = "2006-01-02"
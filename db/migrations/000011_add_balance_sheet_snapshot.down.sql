@@ -0,0 +1,2 @@
+DROP TABLE balance_sheet_snapshot_detail;
+DROP TABLE balance_sheet_snapshot;
@@ -0,0 +1,28 @@
+CREATE TABLE balance_sheet_snapshot (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    total_asset VARCHAR(100) NOT NULL,
+    total_liability VARCHAR(100) NOT NULL,
+    net_worth VARCHAR(100) NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_bs_snapshot_user FOREIGN KEY (user_id) REFERENCES user(id)
+        ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT uq_bs_snapshot_user_period UNIQUE (user_id, start_date)
+);
+
+CREATE INDEX idx_bs_snapshot_user_id ON balance_sheet_snapshot(user_id);
+
+CREATE TABLE balance_sheet_snapshot_detail (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    snapshot_id BIGINT NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    reference_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    value VARCHAR(100) NOT NULL,
+    CONSTRAINT fk_bs_snapshot_detail_snapshot FOREIGN KEY (snapshot_id) REFERENCES balance_sheet_snapshot(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_bs_snapshot_detail_snapshot_id ON balance_sheet_snapshot_detail(snapshot_id);
@@ -0,0 +1,7 @@
+ALTER TABLE user_income_category
+DROP FOREIGN KEY fk_income_cat_parent,
+DROP COLUMN parent_id;
+
+ALTER TABLE user_expense_category
+DROP FOREIGN KEY fk_expense_cat_parent,
+DROP COLUMN parent_id;
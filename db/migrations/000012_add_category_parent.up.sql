@@ -0,0 +1,9 @@
+ALTER TABLE user_income_category
+ADD COLUMN parent_id BIGINT NULL,
+ADD CONSTRAINT fk_income_cat_parent FOREIGN KEY (parent_id) REFERENCES user_income_category(id)
+    ON DELETE RESTRICT ON UPDATE CASCADE;
+
+ALTER TABLE user_expense_category
+ADD COLUMN parent_id BIGINT NULL,
+ADD CONSTRAINT fk_expense_cat_parent FOREIGN KEY (parent_id) REFERENCES user_expense_category(id)
+    ON DELETE RESTRICT ON UPDATE CASCADE;
@@ -0,0 +1,14 @@
+CREATE TABLE budget (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    category_id BIGINT NOT NULL,
+    period CHAR(7) NOT NULL,
+    value VARCHAR(100) NOT NULL,
+    user_id BIGINT NOT NULL,
+    CONSTRAINT fk_budget_user FOREIGN KEY (user_id) REFERENCES user(id)
+        ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_budget_category FOREIGN KEY (category_id) REFERENCES user_expense_category(id)
+        ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT uq_budget_user_category_period UNIQUE (user_id, category_id, period)
+);
+
+CREATE INDEX idx_budget_user_id ON budget(user_id);
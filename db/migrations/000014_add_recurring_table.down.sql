@@ -0,0 +1,11 @@
+ALTER TABLE expense
+DROP FOREIGN KEY fk_expense_recurring,
+DROP INDEX uq_expense_recurring_date,
+DROP COLUMN recurring_id;
+
+ALTER TABLE income
+DROP FOREIGN KEY fk_income_recurring,
+DROP INDEX uq_income_recurring_date,
+DROP COLUMN recurring_id;
+
+DROP TABLE recurring;
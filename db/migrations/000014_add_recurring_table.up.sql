@@ -0,0 +1,28 @@
+CREATE TABLE recurring (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    type VARCHAR(10) NOT NULL,
+    category_id BIGINT NOT NULL,
+    frequency VARCHAR(20) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NULL,
+    value VARCHAR(100) NOT NULL,
+    notes VARCHAR(255),
+    last_generated_date DATE NULL,
+    user_id BIGINT NOT NULL,
+    CONSTRAINT fk_recurring_user FOREIGN KEY (user_id) REFERENCES user(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_recurring_user_id ON recurring(user_id);
+
+ALTER TABLE income
+ADD COLUMN recurring_id BIGINT NULL,
+ADD CONSTRAINT fk_income_recurring FOREIGN KEY (recurring_id) REFERENCES recurring(id)
+    ON DELETE SET NULL ON UPDATE CASCADE,
+ADD CONSTRAINT uq_income_recurring_date UNIQUE (recurring_id, date);
+
+ALTER TABLE expense
+ADD COLUMN recurring_id BIGINT NULL,
+ADD CONSTRAINT fk_expense_recurring FOREIGN KEY (recurring_id) REFERENCES recurring(id)
+    ON DELETE SET NULL ON UPDATE CASCADE,
+ADD CONSTRAINT uq_expense_recurring_date UNIQUE (recurring_id, date);
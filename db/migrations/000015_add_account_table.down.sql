@@ -0,0 +1,9 @@
+ALTER TABLE expense
+DROP FOREIGN KEY fk_expense_account,
+DROP COLUMN account_id;
+
+ALTER TABLE income
+DROP FOREIGN KEY fk_income_account,
+DROP COLUMN account_id;
+
+DROP TABLE account;
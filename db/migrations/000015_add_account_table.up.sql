@@ -0,0 +1,21 @@
+CREATE TABLE account (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    opening_balance VARCHAR(100) NOT NULL,
+    user_id BIGINT NOT NULL,
+    CONSTRAINT fk_account_user FOREIGN KEY (user_id) REFERENCES user(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_account_user_id ON account(user_id);
+
+ALTER TABLE income
+ADD COLUMN account_id BIGINT NULL,
+ADD CONSTRAINT fk_income_account FOREIGN KEY (account_id) REFERENCES account(id)
+    ON DELETE RESTRICT ON UPDATE CASCADE;
+
+ALTER TABLE expense
+ADD COLUMN account_id BIGINT NULL,
+ADD CONSTRAINT fk_expense_account FOREIGN KEY (account_id) REFERENCES account(id)
+    ON DELETE RESTRICT ON UPDATE CASCADE;
@@ -0,0 +1,17 @@
+CREATE TABLE transfer (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    from_account_id BIGINT NOT NULL,
+    to_account_id BIGINT NOT NULL,
+    date DATETIME NOT NULL,
+    value VARCHAR(100) NOT NULL,
+    notes VARCHAR(255),
+    user_id BIGINT NOT NULL,
+    CONSTRAINT fk_transfer_user FOREIGN KEY (user_id) REFERENCES user(id)
+        ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_transfer_from_account FOREIGN KEY (from_account_id) REFERENCES account(id)
+        ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT fk_transfer_to_account FOREIGN KEY (to_account_id) REFERENCES account(id)
+        ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_transfer_user_id ON transfer(user_id);
@@ -0,0 +1,10 @@
+DROP TABLE exchange_rate;
+
+ALTER TABLE balance_sheet_snapshot DROP COLUMN currency;
+ALTER TABLE recurring DROP COLUMN currency;
+ALTER TABLE liability DROP COLUMN currency;
+ALTER TABLE asset DROP COLUMN currency;
+ALTER TABLE expense DROP COLUMN currency;
+ALTER TABLE income DROP COLUMN currency;
+
+ALTER TABLE user DROP COLUMN base_currency;
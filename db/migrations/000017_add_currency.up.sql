@@ -0,0 +1,20 @@
+ALTER TABLE user ADD COLUMN base_currency CHAR(3) NOT NULL DEFAULT 'IDR';
+
+ALTER TABLE income ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE expense ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE asset ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE liability ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE recurring ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE balance_sheet_snapshot ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+
+CREATE TABLE exchange_rate (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    from_currency CHAR(3) NOT NULL,
+    to_currency CHAR(3) NOT NULL,
+    date DATE NOT NULL,
+    rate DECIMAL(30, 8) NOT NULL,
+    user_id BIGINT NOT NULL,
+    CONSTRAINT fk_exchange_rate_user FOREIGN KEY (user_id) REFERENCES user(id)
+        ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT uq_exchange_rate_user_pair_date UNIQUE (user_id, from_currency, to_currency, date)
+);
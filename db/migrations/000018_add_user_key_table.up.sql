@@ -0,0 +1,13 @@
+CREATE TABLE user_key (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    wrapped_key VARCHAR(255) NOT NULL,
+    master_version INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_key_user FOREIGN KEY (user_id) REFERENCES user(id)
+        ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT uq_user_key_user_version UNIQUE (user_id, version)
+);
+
+CREATE INDEX idx_user_key_master_version ON user_key(master_version);
@@ -0,0 +1 @@
+DROP TABLE reencrypt_progress;
@@ -0,0 +1,9 @@
+CREATE TABLE reencrypt_progress (
+    table_name VARCHAR(64) NOT NULL,
+    from_version INT NOT NULL,
+    to_version INT NOT NULL,
+    last_id BIGINT NOT NULL DEFAULT 0,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (table_name, from_version, to_version)
+);
@@ -0,0 +1,2 @@
+DROP TABLE cashflow_aggregate;
+DROP TABLE cashflow_aggregate_state;
@@ -0,0 +1,20 @@
+CREATE TABLE cashflow_aggregate_state (
+    user_id BIGINT PRIMARY KEY,
+    day_of_month TINYINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_cashflow_aggregate_state_user FOREIGN KEY (user_id) REFERENCES user(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE cashflow_aggregate (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    period_start DATE NOT NULL,
+    category_id BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    value VARCHAR(100) NOT NULL,
+    CONSTRAINT fk_cashflow_aggregate_user FOREIGN KEY (user_id) REFERENCES user(id)
+        ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT uq_cashflow_aggregate UNIQUE (user_id, type, period_start, category_id, currency)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE expense DROP INDEX uq_expense_user_external_id;
+ALTER TABLE expense DROP COLUMN external_id;
+
+ALTER TABLE income DROP INDEX uq_income_user_external_id;
+ALTER TABLE income DROP COLUMN external_id;
@@ -0,0 +1,5 @@
+ALTER TABLE income ADD COLUMN external_id VARCHAR(255) NULL;
+ALTER TABLE income ADD CONSTRAINT uq_income_user_external_id UNIQUE (user_id, external_id);
+
+ALTER TABLE expense ADD COLUMN external_id VARCHAR(255) NULL;
+ALTER TABLE expense ADD CONSTRAINT uq_expense_user_external_id UNIQUE (user_id, external_id);
@@ -0,0 +1,15 @@
+CREATE TABLE category_rule (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    type VARCHAR(10) NOT NULL,
+    category_id BIGINT NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    notes_contains VARCHAR(255) NULL,
+    notes_pattern VARCHAR(255) NULL,
+    min_value VARCHAR(100) NULL,
+    max_value VARCHAR(100) NULL,
+    user_id BIGINT NOT NULL,
+    CONSTRAINT fk_category_rule_user FOREIGN KEY (user_id) REFERENCES user(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_category_rule_user_id ON category_rule(user_id);
@@ -0,0 +1,3 @@
+DROP TABLE expense_tag;
+DROP TABLE income_tag;
+DROP TABLE tag;
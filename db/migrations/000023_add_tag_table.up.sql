@@ -0,0 +1,31 @@
+CREATE TABLE tag (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    user_id BIGINT NOT NULL,
+    CONSTRAINT fk_tag_user FOREIGN KEY (user_id) REFERENCES user(id)
+        ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT uq_tag_user_name UNIQUE (user_id, name)
+);
+
+CREATE TABLE income_tag (
+    income_id BIGINT NOT NULL,
+    tag_id BIGINT NOT NULL,
+    PRIMARY KEY (income_id, tag_id),
+    CONSTRAINT fk_income_tag_income FOREIGN KEY (income_id) REFERENCES income(id)
+        ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_income_tag_tag FOREIGN KEY (tag_id) REFERENCES tag(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE expense_tag (
+    expense_id BIGINT NOT NULL,
+    tag_id BIGINT NOT NULL,
+    PRIMARY KEY (expense_id, tag_id),
+    CONSTRAINT fk_expense_tag_expense FOREIGN KEY (expense_id) REFERENCES expense(id)
+        ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_expense_tag_tag FOREIGN KEY (tag_id) REFERENCES tag(id)
+        ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX idx_income_tag_tag_id ON income_tag(tag_id);
+CREATE INDEX idx_expense_tag_tag_id ON expense_tag(tag_id);
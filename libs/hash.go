@@ -8,6 +8,8 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 
 	"github.com/fazriegi/money_management-be/config"
 	"golang.org/x/crypto/bcrypt"
@@ -28,79 +30,157 @@ func deriveKey(keyStr string) []byte {
 	return h[:] // 32 bytes for AES-256
 }
 
+// Encrypt seals value with the current data key of the user whose id is
+// keyStr. The result is prefixed with the key version, e.g. "v1:...", so it
+// can still be opened after the user's data key is rotated.
 func Encrypt(keyStr, value string) (string, error) {
+	return EncryptVersion(keyStr, value, CurrentKeyVersion())
+}
+
+// EncryptVersion is Encrypt with an explicit data key version. Version 0 is
+// the legacy format: the key is sha256 of keyStr and there is no prefix. An
+// empty keyStr always uses the legacy format with secret.encryptionKey.
+func EncryptVersion(keyStr, value string, version int) (string, error) {
 	if keyStr == "" {
-		keyStr = config.GetConfigString("secret.encryptionKey")
-		if keyStr == "" {
-			return "", fmt.Errorf("encryption key is not set in config")
-		}
+		version = LegacyKeyVersion
 	}
 
-	key := deriveKey(keyStr)
-	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
-		return "", fmt.Errorf("invalid key size: must be 16, 24, or 32 bytes")
+	key, err := encryptionKey(keyStr, version, true)
+	if err != nil {
+		return "", err
 	}
 
-	block, err := aes.NewCipher(key)
+	output, err := seal(key, []byte(value), nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create cipher: %w", err)
+		return "", err
 	}
 
-	aesGCM, err := cipher.NewGCM(block)
+	encoded := base64.StdEncoding.EncodeToString(output)
+	if version == LegacyKeyVersion {
+		return encoded, nil
+	}
+
+	return fmt.Sprintf("v%d:%s", version, encoded), nil
+}
+
+// Decrypt opens a value written by Encrypt in any format, the key version is
+// read from the ciphertext
+func Decrypt(keyStr, encodedCipher string) (string, error) {
+	version, payload := splitCipher(encodedCipher)
+
+	key, err := encryptionKey(keyStr, version, false)
 	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+		return "", err
 	}
 
-	nonce := make([]byte, aesGCM.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64 ciphertext: %w", err)
+	}
+
+	plaintext, err := open(key, data, nil)
+	if err != nil {
+		return "", err
 	}
 
-	cipherData := aesGCM.Seal(nil, nonce, []byte(value), nil)
-	output := append(nonce, cipherData...)
+	return string(plaintext), nil
+}
 
-	return base64.StdEncoding.EncodeToString(output), nil
+// CipherVersion returns the data key version a value was encrypted with
+func CipherVersion(encodedCipher string) int {
+	version, _ := splitCipher(encodedCipher)
+	return version
 }
 
-func Decrypt(keyStr, encodedCipher string) (string, error) {
+func splitCipher(encodedCipher string) (int, string) {
+	// base64 never contains ':', so only versioned values have a prefix
+	prefix, payload, found := strings.Cut(encodedCipher, ":")
+	if !found || !strings.HasPrefix(prefix, "v") {
+		return LegacyKeyVersion, encodedCipher
+	}
+
+	version, err := strconv.Atoi(prefix[1:])
+	if err != nil {
+		return LegacyKeyVersion, encodedCipher
+	}
+
+	return version, payload
+}
+
+func encryptionKey(keyStr string, version int, create bool) ([]byte, error) {
 	if keyStr == "" {
 		keyStr = config.GetConfigString("secret.encryptionKey")
 		if keyStr == "" {
-			return "", fmt.Errorf("encryption key is not set in config")
+			return nil, fmt.Errorf("encryption key is not set in config")
 		}
+
+		return deriveKey(keyStr), nil
+	}
+
+	if version == LegacyKeyVersion {
+		return deriveKey(keyStr), nil
+	}
+
+	userId, err := strconv.ParseUint(keyStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key owner %q: %w", keyStr, err)
 	}
 
-	key := deriveKey(keyStr)
+	return dataKey(uint(userId), version, create)
+}
+
+// seal encrypts with AES-GCM and returns the nonce followed by the ciphertext
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
 	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
-		return "", fmt.Errorf("invalid key size: must be 16, 24, or 32 bytes")
+		return nil, fmt.Errorf("invalid key size: must be 16, 24, or 32 bytes")
 	}
 
-	data, err := base64.StdEncoding.DecodeString(encodedCipher)
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode base64 ciphertext: %w", err)
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aesGCM, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	nonce := make([]byte, aesGCM.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	cipherData := aesGCM.Seal(nil, nonce, plaintext, additionalData)
+
+	return append(nonce, cipherData...), nil
+}
+
+func open(key, data, additionalData []byte) ([]byte, error) {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, fmt.Errorf("invalid key size: must be 16, 24, or 32 bytes")
 	}
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", fmt.Errorf("failed to create cipher: %w", err)
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
 	}
 
 	aesGCM, err := cipher.NewGCM(block)
 	if err != nil {
-		return "", fmt.Errorf("failed to create GCM: %w", err)
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
 	}
 
 	nonceSize := aesGCM.NonceSize()
 	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
+		return nil, fmt.Errorf("ciphertext too short")
 	}
 
 	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
 
-	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, additionalData)
 	if err != nil {
-		return "", fmt.Errorf("decryption failed: %w", err)
+		return nil, fmt.Errorf("decryption failed: %w", err)
 	}
 
-	return string(plaintext), nil
+	return plaintext, nil
 }
@@ -1,5 +1,7 @@
 package libs
 
+import "time"
+
 func Intersection[T comparable](slice1, slice2 []T) []T {
 	set := make(map[T]struct{})
 	for _, v := range slice1 {
@@ -15,3 +17,69 @@ func Intersection[T comparable](slice1, slice2 []T) []T {
 
 	return matches
 }
+
+// Descendants returns id followed by the ids of every node below it. parents
+// maps a child id to its parent id.
+func Descendants(id uint, parents map[uint]uint) []uint {
+	children := make(map[uint][]uint)
+	for child, parent := range parents {
+		children[parent] = append(children[parent], child)
+	}
+
+	result := []uint{id}
+	visited := map[uint]struct{}{id: {}}
+	for i := 0; i < len(result); i++ {
+		for _, child := range children[result[i]] {
+			if _, ok := visited[child]; ok {
+				continue
+			}
+
+			visited[child] = struct{}{}
+			result = append(result, child)
+		}
+	}
+
+	return result
+}
+
+// Ancestors returns the ids above id, nearest first. parents maps a child id
+// to its parent id.
+func Ancestors(id uint, parents map[uint]uint) []uint {
+	var result []uint
+	visited := map[uint]struct{}{id: {}}
+	for {
+		parent, ok := parents[id]
+		if !ok {
+			return result
+		}
+
+		if _, ok := visited[parent]; ok {
+			return result
+		}
+
+		visited[parent] = struct{}{}
+		result = append(result, parent)
+		id = parent
+	}
+}
+
+// ParseDate reads a date column scanned into an interface{}, which is a
+// time.Time from the driver or a "2006-01-02" string from a request. Anything
+// else gives the zero time.
+func ParseDate(value interface{}) time.Time {
+	switch v := value.(type) {
+	case time.Time:
+		return v
+	case []byte:
+		return ParseDate(string(v))
+	case string:
+		if len(v) > 10 {
+			v = v[:10]
+		}
+
+		date, _ := time.Parse("2006-01-02", v)
+		return date
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,42 @@
+package libs
+
+import (
+	"slices"
+	"testing"
+)
+
+// 1 is the parent of 2 and 3, 2 is the parent of 4, and 5 and 6 are
+// parents of each other
+var testParents = map[uint]uint{2: 1, 3: 1, 4: 2, 5: 6, 6: 5}
+
+func TestDescendants(t *testing.T) {
+	got := Descendants(1, testParents)
+	slices.Sort(got)
+	if want := []uint{1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("Descendants(1) = %v, want %v", got, want)
+	}
+
+	if got := Descendants(4, testParents); !slices.Equal(got, []uint{4}) {
+		t.Errorf("Descendants(4) = %v, want [4]", got)
+	}
+
+	got = Descendants(5, testParents)
+	slices.Sort(got)
+	if want := []uint{5, 6}; !slices.Equal(got, want) {
+		t.Errorf("Descendants(5) = %v, want %v", got, want)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	if got, want := Ancestors(4, testParents), []uint{2, 1}; !slices.Equal(got, want) {
+		t.Errorf("Ancestors(4) = %v, want %v", got, want)
+	}
+
+	if got := Ancestors(1, testParents); len(got) != 0 {
+		t.Errorf("Ancestors(1) = %v, want none", got)
+	}
+
+	if got, want := Ancestors(5, testParents), []uint{6}; !slices.Equal(got, want) {
+		t.Errorf("Ancestors(5) = %v, want %v", got, want)
+	}
+}
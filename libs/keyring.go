@@ -0,0 +1,342 @@
+package libs
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/jmoiron/sqlx"
+)
+
+// Values are encrypted with a random data key per user. Data keys are stored
+// in user_key wrapped by a master key, so reading the database alone is not
+// enough to decrypt anything. Version 0 is the scheme used before, where the
+// AES key is sha256 of the user id.
+//
+// Rotating the master key needs no downtime: add the new key to
+// secret.masterKeys and point secret.masterKeyVersion at it. RewrapDataKeys
+// then rewraps the data keys in the background while the old master key still
+// opens the ones it has not reached. Remove the old key once it reports
+// nothing left to rewrap.
+
+// LegacyKeyVersion marks values written before data keys existed
+const LegacyKeyVersion = 0
+
+var ErrUnknownKeyVersion = errors.New("unknown key version")
+
+type userKey struct {
+	ID            uint   `db:"id"`
+	UserId        uint   `db:"user_id"`
+	Version       int    `db:"version"`
+	WrappedKey    string `db:"wrapped_key"`
+	MasterVersion int    `db:"master_version"`
+}
+
+type dataKeyId struct {
+	userId  uint
+	version int
+}
+
+// dataKeys caches unwrapped data keys. A rewrap does not change a data key,
+// so entries never go stale.
+var dataKeys = struct {
+	sync.RWMutex
+	keys map[dataKeyId][]byte
+}{keys: make(map[dataKeyId][]byte)}
+
+// CurrentKeyVersion is the data key version new values are encrypted with,
+// set by secret.dataKeyVersion. It defaults to 1, and 0 keeps writing the
+// legacy format.
+func CurrentKeyVersion() int {
+	if !config.IsConfigSet("secret.dataKeyVersion") {
+		return 1
+	}
+
+	return config.GetConfigInt("secret.dataKeyVersion")
+}
+
+// ActiveMasterVersion is the master key version new data keys are wrapped
+// with, set by secret.masterKeyVersion and 1 by default
+func ActiveMasterVersion() int {
+	if version := config.GetConfigInt("secret.masterKeyVersion"); version > 0 {
+		return version
+	}
+
+	return 1
+}
+
+// masterKey returns the master key of a version from secret.masterKeys.
+// secret.encryptionKey is version 1, so existing configs keep working.
+func masterKey(version int) ([]byte, error) {
+	keyStr := config.GetConfigStringMap("secret.masterKeys")[strconv.Itoa(version)]
+	if keyStr == "" && version == 1 {
+		keyStr = config.GetConfigString("secret.encryptionKey")
+	}
+
+	if keyStr == "" {
+		return nil, fmt.Errorf("%w: master key %d is not set in config", ErrUnknownKeyVersion, version)
+	}
+
+	return deriveKey(keyStr), nil
+}
+
+// dataKey returns the data key of a user, creating it when create is set and
+// the user has none of that version yet
+func dataKey(userId uint, version int, create bool) ([]byte, error) {
+	id := dataKeyId{userId, version}
+
+	dataKeys.RLock()
+	key, ok := dataKeys.keys[id]
+	dataKeys.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	db := config.GetDatabase()
+
+	row, err := getUserKey(userId, version, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) && create {
+		row, err = createUserKey(userId, version, db)
+	}
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: data key %d of user %d", ErrUnknownKeyVersion, version, userId)
+	} else if err != nil {
+		return nil, err
+	}
+
+	key, err = unwrapKey(&row)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKeys.Lock()
+	dataKeys.keys[id] = key
+	dataKeys.Unlock()
+
+	return key, nil
+}
+
+func createUserKey(userId uint, version int, db *sqlx.DB) (result userKey, err error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return result, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	masterVersion := ActiveMasterVersion()
+	wrapped, err := wrapKey(userId, version, masterVersion, key)
+	if err != nil {
+		return result, err
+	}
+
+	err = insertUserKey(&userKey{
+		UserId:        userId,
+		Version:       version,
+		WrappedKey:    wrapped,
+		MasterVersion: masterVersion,
+	}, db)
+	if err != nil {
+		return result, err
+	}
+
+	// read it back, another request may have created the key first
+	return getUserKey(userId, version, db)
+}
+
+// keyAdditionalData binds a wrapped key to its owner and version, so a row
+// copied onto another user does not open
+func keyAdditionalData(userId uint, version int) []byte {
+	return []byte(fmt.Sprintf("%d/%d", userId, version))
+}
+
+func wrapKey(userId uint, version, masterVersion int, key []byte) (string, error) {
+	master, err := masterKey(masterVersion)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := seal(master, key, keyAdditionalData(userId, version))
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func unwrapKey(row *userKey) ([]byte, error) {
+	master, err := masterKey(row.MasterVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(row.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode wrapped data key: %w", err)
+	}
+
+	key, err := open(master, data, keyAdditionalData(row.UserId, row.Version))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	return key, nil
+}
+
+// RewrapDataKeys wraps every data key still wrapped by an older master key
+// with the active one. The data keys themselves do not change, so no value
+// has to be re-encrypted.
+func RewrapDataKeys() {
+	log := config.GetLogger()
+	db := config.GetDatabase()
+	active := ActiveMasterVersion()
+
+	rows, err := listUserKeyToRewrap(active, db)
+	if err != nil {
+		log.Errorf("listUserKeyToRewrap: %s", err.Error())
+		return
+	}
+
+	if len(rows) == 0 {
+		return
+	}
+
+	var rewrapped int
+	for _, row := range rows {
+		key, err := unwrapKey(&row)
+		if err != nil {
+			log.Errorf("failed unwrap data key %d: %s", row.ID, err.Error())
+			continue
+		}
+
+		wrapped, err := wrapKey(row.UserId, row.Version, active, key)
+		if err != nil {
+			log.Errorf("failed wrap data key %d: %s", row.ID, err.Error())
+			continue
+		}
+
+		if err := updateUserKey(&row, wrapped, active, db); err != nil {
+			log.Errorf("updateUserKey: %s", err.Error())
+			continue
+		}
+
+		rewrapped++
+	}
+
+	log.Infof("rewrapped %d of %d data key(s) with master key version %d", rewrapped, len(rows), active)
+}
+
+func getUserKey(userId uint, version int, db *sqlx.DB) (result userKey, err error) {
+	dialect := GetDialect()
+
+	dataset := dialect.From("user_key").
+		Select(
+			goqu.I("id"),
+			goqu.I("user_id"),
+			goqu.I("version"),
+			goqu.I("wrapped_key"),
+			goqu.I("master_version"),
+		).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("version").Eq(version),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func insertUserKey(data *userKey, db *sqlx.DB) error {
+	dialect := GetDialect()
+
+	// the unique (user_id, version) key makes a concurrent insert a no-op
+	dataset := dialect.Insert("user_key").
+		Rows(goqu.Record{
+			"user_id":        data.UserId,
+			"version":        data.Version,
+			"wrapped_key":    data.WrappedKey,
+			"master_version": data.MasterVersion,
+		}).
+		OnConflict(goqu.DoNothing())
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = db.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
+
+func listUserKeyToRewrap(masterVersion int, db *sqlx.DB) (result []userKey, err error) {
+	dialect := GetDialect()
+
+	dataset := dialect.From("user_key").
+		Select(
+			goqu.I("id"),
+			goqu.I("user_id"),
+			goqu.I("version"),
+			goqu.I("wrapped_key"),
+			goqu.I("master_version"),
+		).
+		Where(goqu.I("master_version").Neq(masterVersion))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]userKey, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func updateUserKey(row *userKey, wrappedKey string, masterVersion int, db *sqlx.DB) error {
+	dialect := GetDialect()
+
+	// only rewrap a row that is still as it was read
+	dataset := dialect.Update("user_key").
+		Set(goqu.Record{
+			"wrapped_key":    wrappedKey,
+			"master_version": masterVersion,
+		}).
+		Where(
+			goqu.I("id").Eq(row.ID),
+			goqu.I("master_version").Eq(row.MasterVersion),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = db.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
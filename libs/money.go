@@ -0,0 +1,199 @@
+package libs
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// MoneyScale is the number of fraction digits kept for an amount, enough for
+// cents as well as grams of gold or fractions of a coin
+const MoneyScale = 8
+
+var (
+	ErrInvalidMoney = errors.New("invalid money value")
+
+	moneyUnit = new(big.Int).Exp(big.NewInt(10), big.NewInt(MoneyScale), nil)
+
+	// moneyPattern is a plain decimal with a bounded number of digits. Exponents
+	// such as 1e1000000 would parse into huge integers that no column can hold.
+	moneyPattern = regexp.MustCompile(`^[-+]?(\d{1,20}(\.\d{0,18})?|\.\d{1,18})$`)
+)
+
+// Money is an exact decimal amount. Values are kept as a multiple of
+// 10^-MoneyScale so adding them never accumulates float errors. The zero value
+// is 0 and every method returns a new Money instead of changing the receiver.
+type Money struct {
+	units *big.Int
+}
+
+func NewMoney(value int64) Money {
+	return Money{new(big.Int).Mul(big.NewInt(value), moneyUnit)}
+}
+
+// ParseMoney reads a decimal string such as "12.50" or "-3". Values stored
+// before amounts were exact are rounded integers written with "%0.f", they
+// parse as they are, so existing rows need no rewrite and get the exact form
+// the next time they are saved. Digits beyond MoneyScale are rounded half
+// away from zero. At most 20 integer and 18 fraction digits are accepted.
+func ParseMoney(value string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if !moneyPattern.MatchString(value) {
+		return Money{}, ErrInvalidMoney
+	}
+
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Money{}, ErrInvalidMoney
+	}
+
+	return moneyFromRat(rat), nil
+}
+
+// MustParseMoney is ParseMoney for constant values known to be valid
+func MustParseMoney(value string) Money {
+	m, err := ParseMoney(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+func moneyFromRat(rat *big.Rat) Money {
+	scaled := new(big.Rat).Mul(rat, new(big.Rat).SetInt(moneyUnit))
+
+	quo, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	// round half away from zero
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(scaled.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(scaled.Sign())))
+	}
+
+	return Money{quo}
+}
+
+func (m Money) int() *big.Int {
+	if m.units == nil {
+		return new(big.Int)
+	}
+
+	return m.units
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{new(big.Int).Add(m.int(), other.int())}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{new(big.Int).Sub(m.int(), other.int())}
+}
+
+func (m Money) Neg() Money {
+	return Money{new(big.Int).Neg(m.int())}
+}
+
+// Mul multiplies the amount by a factor such as an exchange rate
+func (m Money) Mul(factor Money) Money {
+	rat := new(big.Rat).SetFrac(new(big.Int).Mul(m.int(), factor.int()), moneyUnit)
+	rat.Quo(rat, new(big.Rat).SetInt(moneyUnit))
+
+	return moneyFromRat(rat)
+}
+
+// Div divides the amount by a non zero divisor, rounding to MoneyScale
+func (m Money) Div(divisor Money) Money {
+	if divisor.IsZero() {
+		return Money{}
+	}
+
+	return moneyFromRat(new(big.Rat).SetFrac(m.int(), divisor.int()))
+}
+
+func (m Money) Cmp(other Money) int {
+	return m.int().Cmp(other.int())
+}
+
+func (m Money) Sign() int {
+	return m.int().Sign()
+}
+
+func (m Money) IsZero() bool {
+	return m.Sign() == 0
+}
+
+// Float64 returns the nearest float, meant for ratios such as percentages
+// and never for amounts that are stored or summed
+func (m Money) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(m.int(), moneyUnit).Float64()
+	return f
+}
+
+// String returns the shortest exact decimal form, e.g. "12.5"
+func (m Money) String() string {
+	s := new(big.Rat).SetFrac(m.int(), moneyUnit).FloatString(MoneyScale)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+
+	return s
+}
+
+// MarshalJSON writes the amount as a JSON number so responses keep the shape
+// they had when amounts were floats
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string. The raw text is
+// parsed, so 12.50 never passes through a float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*m = Money{}
+		return nil
+	}
+
+	value, err := ParseMoney(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+
+	*m = value
+	return nil
+}
+
+// Scan reads a plain numeric column, such as a DECIMAL exchange rate
+func (m *Money) Scan(src any) error {
+	var value string
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	case int64:
+		*m = NewMoney(v)
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into money", src)
+	}
+
+	parsed, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,129 @@
+package libs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"integer", "12", "12"},
+		{"legacy rounded integer", "150000", "150000"},
+		{"fraction", "12.50", "12.5"},
+		{"negative", "-3.25", "-3.25"},
+		{"plus sign", "+7", "7"},
+		{"leading dot", ".5", "0.5"},
+		{"trailing dot", "12.", "12"},
+		{"surrounding spaces", " 4.2 ", "4.2"},
+		{"twenty integer digits", "99999999999999999999", "99999999999999999999"},
+		{"half rounds up", "0.000000005", "0.00000001"},
+		{"half rounds away from zero", "-0.000000005", "-0.00000001"},
+		{"below half rounds down", "0.0000000049", "0"},
+		{"below half rounds toward zero", "-0.0000000049", "0"},
+		{"rounding carries", "1.999999999", "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.value)
+			if err != nil {
+				t.Fatalf("ParseMoney(%q) error = %v", tt.value, err)
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("ParseMoney(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMoneyInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"empty", ""},
+		{"letters", "abc"},
+		{"fraction syntax", "1/3"},
+		{"exponent", "1e5"},
+		{"huge exponent", "1e1000000"},
+		{"too many integer digits", "100000000000000000000"},
+		{"too many fraction digits", "0.1234567890123456789"},
+		{"thousands separator", "1,000"},
+		{"only sign", "-"},
+		{"only dot", "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMoney(tt.value)
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("ParseMoney(%q) error = %v, want %v", tt.value, err, ErrInvalidMoney)
+			}
+		})
+	}
+}
+
+func TestMoneyMul(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		factor string
+		want   string
+	}{
+		{"exact", "12.5", "2", "25"},
+		{"exchange rate", "100", "15750.25", "1575025"},
+		{"half rounds up", "0.00000001", "0.5", "0.00000001"},
+		{"half rounds away from zero", "-0.00000001", "0.5", "-0.00000001"},
+		{"below half rounds down", "0.00000001", "0.4", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MustParseMoney(tt.value).Mul(MustParseMoney(tt.factor))
+			if got.String() != tt.want {
+				t.Errorf("%s * %s = %s, want %s", tt.value, tt.factor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyDiv(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		divisor string
+		want    string
+	}{
+		{"exact", "10", "4", "2.5"},
+		{"rounds down", "1", "3", "0.33333333"},
+		{"rounds up", "2", "3", "0.66666667"},
+		{"rounds away from zero", "-2", "3", "-0.66666667"},
+		{"half rounds away from zero", "-0.00000001", "2", "-0.00000001"},
+		{"zero divisor", "5", "0", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MustParseMoney(tt.value).Div(MustParseMoney(tt.divisor))
+			if got.String() != tt.want {
+				t.Errorf("%s / %s = %s, want %s", tt.value, tt.divisor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyAddIsExact(t *testing.T) {
+	var total Money
+	for range 10 {
+		total = total.Add(MustParseMoney("0.1"))
+	}
+
+	if total.Cmp(NewMoney(1)) != 0 {
+		t.Errorf("0.1 added 10 times = %s, want 1", total)
+	}
+}
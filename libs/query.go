@@ -1,6 +1,7 @@
 package libs
 
 import (
+	"database/sql"
 	"errors"
 	"reflect"
 	"strings"
@@ -61,66 +62,97 @@ func ScanRowsIntoStructs(rows *sqlx.Rows, destSlice interface{}) error {
 
 	for rows.Next() {
 		elem := reflect.New(sliceType).Elem()
-		// Create a DB column → struct field mapping based on the db tag
-		fieldMap := make(map[string]reflect.Value)
-		for i := 0; i < elem.NumField(); i++ {
-			field := sliceType.Field(i)
-			tag := field.Tag.Get("db")
-			if tag == "" {
-				continue
-			}
-			fieldMap[tag] = elem.Field(i)
+		if err := scanRow(rows, columns, columnTypes, elem); err != nil {
+			return err
 		}
 
-		// Prepare a place to scan query results
-		scanArgs := make([]interface{}, len(columns))
-		for i, col := range columns {
-			if field, exists := fieldMap[col]; exists {
-				// Handle interface{} fields by scanning into appropriate types
-				if field.Kind() == reflect.Interface {
-					switch columnTypes[i].DatabaseTypeName() {
-					case "INT", "INTEGER", "BIGINT":
-						var v int64
-						scanArgs[i] = &v
-					case "FLOAT", "REAL", "DOUBLE":
-						var v float64
-						scanArgs[i] = &v
-					case "DECIMAL", "NUMERIC":
-						var v float64
-						scanArgs[i] = &v
-					case "VARCHAR", "TEXT", "STRING":
-						var v string
-						scanArgs[i] = &v
-					default:
-						// Fallback to interface{} for unknown types
-						scanArgs[i] = field.Addr().Interface()
-					}
-				} else {
+		destVal.Elem().Set(reflect.Append(destVal.Elem(), elem))
+	}
+
+	return rows.Err()
+}
+
+// ScanRowIntoStruct scans the current row the same way ScanRowsIntoStructs
+// does, for callers that stream rows instead of collecting them
+func ScanRowIntoStruct(rows *sqlx.Rows, dest interface{}) error {
+	destVal := reflect.ValueOf(dest)
+	if destVal.Kind() != reflect.Ptr || destVal.Elem().Kind() != reflect.Struct {
+		return errors.New("dest must be a pointer to a struct")
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	return scanRow(rows, columns, columnTypes, destVal.Elem())
+}
+
+func scanRow(rows *sqlx.Rows, columns []string, columnTypes []*sql.ColumnType, elem reflect.Value) error {
+	elemType := elem.Type()
+
+	// Create a DB column → struct field mapping based on the db tag
+	fieldMap := make(map[string]reflect.Value)
+	for i := 0; i < elem.NumField(); i++ {
+		field := elemType.Field(i)
+		tag := field.Tag.Get("db")
+		if tag == "" {
+			continue
+		}
+		fieldMap[tag] = elem.Field(i)
+	}
+
+	// Prepare a place to scan query results
+	scanArgs := make([]interface{}, len(columns))
+	for i, col := range columns {
+		if field, exists := fieldMap[col]; exists {
+			// Handle interface{} fields by scanning into appropriate types
+			if field.Kind() == reflect.Interface {
+				switch columnTypes[i].DatabaseTypeName() {
+				case "INT", "INTEGER", "BIGINT":
+					var v int64
+					scanArgs[i] = &v
+				case "FLOAT", "REAL", "DOUBLE":
+					var v float64
+					scanArgs[i] = &v
+				case "DECIMAL", "NUMERIC":
+					var v float64
+					scanArgs[i] = &v
+				case "VARCHAR", "TEXT", "STRING":
+					var v string
+					scanArgs[i] = &v
+				default:
+					// Fallback to interface{} for unknown types
 					scanArgs[i] = field.Addr().Interface()
 				}
 			} else {
-				// Ignore unused columns
-				var dummy interface{}
-				scanArgs[i] = &dummy
+				scanArgs[i] = field.Addr().Interface()
 			}
+		} else {
+			// Ignore unused columns
+			var dummy interface{}
+			scanArgs[i] = &dummy
 		}
+	}
 
-		if err := rows.Scan(scanArgs...); err != nil {
-			return err
-		}
+	if err := rows.Scan(scanArgs...); err != nil {
+		return err
+	}
 
-		// Convert scanned values to interface{} for target fields
-		for i, col := range columns {
-			if field, exists := fieldMap[col]; exists && field.Kind() == reflect.Interface {
-				val := reflect.ValueOf(scanArgs[i]).Elem().Interface()
-				field.Set(reflect.ValueOf(val))
-			}
+	// Convert scanned values to interface{} for target fields
+	for i, col := range columns {
+		if field, exists := fieldMap[col]; exists && field.Kind() == reflect.Interface {
+			val := reflect.ValueOf(scanArgs[i]).Elem().Interface()
+			field.Set(reflect.ValueOf(val))
 		}
-
-		destVal.Elem().Set(reflect.Append(destVal.Elem(), elem))
 	}
 
-	return rows.Err()
+	return nil
 }
 
 func GetDialect() goqu.DialectWrapper {
@@ -0,0 +1,30 @@
+package libs
+
+import (
+	"time"
+
+	"github.com/fazriegi/money_management-be/config"
+)
+
+// RunEvery calls fn right away and then once every interval. It blocks, so
+// callers run it in its own goroutine.
+func RunEvery(interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		run(fn)
+		<-ticker.C
+	}
+}
+
+// run keeps a panicking job from taking the whole server down
+func run(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			config.GetLogger().Errorf("recovered from job panic: %v", r)
+		}
+	}()
+
+	fn()
+}
@@ -20,6 +20,9 @@ func ValidateRequest(data any) []ValidationErrResponse {
 	validate := validator.New()
 	validate.RegisterValidation("password", password) // register custom validator
 
+	// validate money fields like plain numbers, e.g. required or gt=0
+	validate.RegisterCustomTypeFunc(moneyValue, Money{})
+
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		name := fld.Tag.Get("json")
 		if name == "-" {
@@ -47,6 +50,14 @@ func ValidateRequest(data any) []ValidationErrResponse {
 	return validationErrors
 }
 
+func moneyValue(field reflect.Value) interface{} {
+	if m, ok := field.Interface().(Money); ok {
+		return m.Float64()
+	}
+
+	return nil
+}
+
 func password(fl validator.FieldLevel) bool {
 	password := fl.Field().String()
 
@@ -3,11 +3,13 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/fazriegi/money_management-be/config"
 	"github.com/fazriegi/money_management-be/libs"
 	"github.com/fazriegi/money_management-be/middleware"
 	"github.com/fazriegi/money_management-be/module"
+	"github.com/fazriegi/money_management-be/module/reencrypt"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -20,6 +22,17 @@ func main() {
 	file := config.NewLogger(viperConfig)
 	defer file.Close()
 
+	// subcommands share the config and database but do not start the server
+	if len(os.Args) > 1 && os.Args[1] == "reencrypt" {
+		if err := reencrypt.Run(os.Args[2:]); err != nil {
+			config.GetLogger().Errorf("reencrypt: %s", err.Error())
+			file.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	app := fiber.New()
 
 	app.Use(cors.New(cors.Config{
@@ -31,6 +44,7 @@ func main() {
 	app.Use(middleware.LogMiddleware())
 	port := viperConfig.GetInt("web.port")
 	module.NewRoute(app, jwt)
+	module.NewJob()
 
 	log.Fatal(app.Listen(fmt.Sprintf(":%d", port)))
 }
@@ -18,6 +18,9 @@ type Controller interface {
 	List(ctx *fiber.Ctx) error
 	Update(ctx *fiber.Ctx) error
 	Delete(ctx *fiber.Ctx) error
+	AddCategory(ctx *fiber.Ctx) error
+	UpdateCategory(ctx *fiber.Ctx) error
+	DeleteCategory(ctx *fiber.Ctx) error
 }
 
 type controller struct {
@@ -140,3 +143,91 @@ func (c *controller) Delete(ctx *fiber.Ctx) error {
 
 	return ctx.Status(response.Status.Code).JSON(response)
 }
+
+func (c *controller) AddCategory(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.CategoryRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing request body: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseReqBodyErr, nil))
+	}
+
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
+	response = c.usecase.AddCategory(&user, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
+
+func (c *controller) UpdateCategory(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.CategoryRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		c.log.Errorf("error get param: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, "invalid id", nil))
+	}
+
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing request body: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseReqBodyErr, nil))
+	}
+
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
+	reqBody.ID = uint(id)
+	response = c.usecase.UpdateCategory(&user, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
+
+func (c *controller) DeleteCategory(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.DeleteCategoryRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		c.log.Errorf("error get param: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, "invalid id", nil))
+	}
+
+	if err := ctx.QueryParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing query param: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseQueryParamErr, nil))
+	}
+
+	reqBody.ID = uint(id)
+	response = c.usecase.DeleteCategory(&user, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
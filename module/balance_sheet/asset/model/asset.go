@@ -1,26 +1,32 @@
 package model
 
-import "github.com/fazriegi/money_management-be/module/common"
+import (
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/common"
+)
 
 type Asset struct {
 	ID         uint   `db:"id"`
 	CategoryId uint   `db:"category_id"`
 	Value      string `db:"value"`
 	Amount     string `db:"amount"`
+	Currency   string `db:"currency"`
 	UserId     uint   `db:"user_id"`
 	Notes      string `db:"notes"`
 }
 
 type AddRequest struct {
-	CategoryId uint    `json:"category_id" validate:"required"`
-	Value      float64 `json:"value" validate:"required"`
-	Amount     float64 `json:"amount" validate:"required"`
-	Notes      string  `json:"notes" validate:"required"`
+	CategoryId uint       `json:"category_id" validate:"required"`
+	Value      libs.Money `json:"value" validate:"required"`
+	Amount     libs.Money `json:"amount" validate:"required"`
+	Currency   string     `json:"currency" validate:"omitempty,iso4217"`
+	Notes      string     `json:"notes" validate:"required"`
 }
 
 type AssetCategory struct {
-	ID   uint   `db:"id" json:"id"`
-	Name string `db:"name" json:"name"`
+	ID     uint   `db:"id" json:"id"`
+	Name   string `db:"name" json:"name"`
+	UserId uint   `db:"user_id" json:"-"`
 }
 
 type ListRequest struct {
@@ -35,23 +41,36 @@ type GetAsset struct {
 	Category   string `db:"category"`
 	Value      string `db:"value"`
 	Amount     string `db:"amount"`
+	Currency   string `db:"currency"`
 	UserId     uint   `db:"user_id"`
 	Notes      string `db:"notes"`
 }
 
 type ListResponse struct {
-	ID         uint    `json:"id"`
-	CategoryId uint    `json:"category_id"`
-	Category   string  `json:"category"`
-	Value      float64 `json:"value"`
-	Amount     float64 `json:"amount"`
-	Notes      string  `json:"notes"`
+	ID         uint       `json:"id"`
+	CategoryId uint       `json:"category_id"`
+	Category   string     `json:"category"`
+	Value      libs.Money `json:"value"`
+	Amount     libs.Money `json:"amount"`
+	Currency   string     `json:"currency"`
+	Notes      string     `json:"notes"`
 }
 
 type UpdateRequest struct {
 	ID         uint
-	CategoryId uint        `json:"category_id" validate:"required"`
-	Amount     interface{} `json:"amount" validate:"required"`
-	Value      float64     `json:"value" validate:"required"`
-	Notes      string      `json:"notes" validate:"required"`
+	CategoryId uint       `json:"category_id" validate:"required"`
+	Amount     libs.Money `json:"amount" validate:"required"`
+	Value      libs.Money `json:"value" validate:"required"`
+	Currency   string     `json:"currency" validate:"omitempty,iso4217"`
+	Notes      string     `json:"notes" validate:"required"`
+}
+
+type CategoryRequest struct {
+	ID   uint
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+type DeleteCategoryRequest struct {
+	ID         uint
+	ReassignTo uint `query:"reassign_to"`
 }
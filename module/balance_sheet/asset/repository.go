@@ -20,6 +20,12 @@ type Repository interface {
 	List(req *model.ListRequest, db *sqlx.DB) (result []model.GetAsset, total uint, err error)
 	Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error
 	Delete(userId, id uint, tx *sqlx.Tx) error
+	GetCategoryById(userId, id uint, db *sqlx.DB) (result model.AssetCategory, err error)
+	InsertCategory(data *model.AssetCategory, tx *sqlx.Tx) error
+	UpdateCategory(userId, id uint, data map[string]any, tx *sqlx.Tx) error
+	DeleteCategory(userId, id uint, tx *sqlx.Tx) error
+	CountByCategory(userId, categoryId uint, tx *sqlx.Tx) (total uint, err error)
+	ReassignCategory(userId, fromId, toId uint, tx *sqlx.Tx) error
 }
 
 type repository struct{}
@@ -90,6 +96,7 @@ func (r *repository) List(req *model.ListRequest, db *sqlx.DB) (result []model.G
 			goqu.I("ac.name").As("category"),
 			goqu.I("asset.amount"),
 			goqu.I("asset.value"),
+			goqu.I("asset.currency"),
 			goqu.I("asset.user_id"),
 		).
 		Where(
@@ -210,3 +217,137 @@ func (r *repository) Delete(userId, id uint, tx *sqlx.Tx) error {
 	return nil
 
 }
+
+func (r *repository) GetCategoryById(userId, id uint, db *sqlx.DB) (result model.AssetCategory, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("asset_category").
+		Select(
+			goqu.I("id"),
+			goqu.I("name"),
+			goqu.I("user_id"),
+		).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&result, sql, val...)
+	if err != nil {
+		return result, err
+	}
+
+	return
+}
+
+func (r *repository) InsertCategory(data *model.AssetCategory, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("asset_category").Rows(*data)
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) UpdateCategory(userId, id uint, data map[string]any, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("asset_category").Set(data).
+		Where(
+			goqu.I("id").Eq(id),
+			goqu.I("user_id").Eq(userId),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteCategory(userId, id uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Delete("asset_category").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) CountByCategory(userId, categoryId uint, tx *sqlx.Tx) (total uint, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("asset").
+		Select(goqu.COUNT("*")).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("category_id").Eq(categoryId),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = tx.Get(&total, sql, val...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) ReassignCategory(userId, fromId, toId uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("asset").
+		Set(goqu.Record{"category_id": toId}).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("category_id").Eq(fromId),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
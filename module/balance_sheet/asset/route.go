@@ -4,13 +4,15 @@ import (
 	"github.com/fazriegi/money_management-be/config"
 	"github.com/fazriegi/money_management-be/libs"
 	"github.com/fazriegi/money_management-be/middleware"
+	"github.com/fazriegi/money_management-be/module/master/currency"
 	"github.com/gofiber/fiber/v2"
 )
 
 func NewRoute(app *fiber.App, jwt *libs.JWT) {
 	log := config.GetLogger()
 	repo := NewRepository()
-	usecase := NewUsecase(log, repo)
+	currencyRepo := currency.NewRepository()
+	usecase := NewUsecase(log, repo, currencyRepo)
 	controller := NewController(log, usecase)
 
 	route := app.Group("/asset")
@@ -19,4 +21,7 @@ func NewRoute(app *fiber.App, jwt *libs.JWT) {
 	route.Put("/:id", middleware.Authentication(jwt), controller.Update)
 	route.Delete("/:id", middleware.Authentication(jwt), controller.Delete)
 	route.Get("/category", middleware.Authentication(jwt), controller.ListCategory)
+	route.Post("/category", middleware.Authentication(jwt), controller.AddCategory)
+	route.Put("/category/:id", middleware.Authentication(jwt), controller.UpdateCategory)
+	route.Delete("/category/:id", middleware.Authentication(jwt), controller.DeleteCategory)
 }
@@ -1,15 +1,17 @@
 package asset
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/fazriegi/money_management-be/config"
 	"github.com/fazriegi/money_management-be/constant"
 	"github.com/fazriegi/money_management-be/libs"
 	"github.com/fazriegi/money_management-be/module/balance_sheet/asset/model"
 	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/currency"
 	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
 	"github.com/sirupsen/logrus"
 )
@@ -20,22 +22,38 @@ type Usecase interface {
 	List(user *userModel.User, req *model.ListRequest) (resp common.Response)
 	Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response)
 	Delete(user *userModel.User, id uint) (resp common.Response)
+	AddCategory(user *userModel.User, req *model.CategoryRequest) (resp common.Response)
+	UpdateCategory(user *userModel.User, req *model.CategoryRequest) (resp common.Response)
+	DeleteCategory(user *userModel.User, req *model.DeleteCategoryRequest) (resp common.Response)
 }
 
 type usecase struct {
-	log  *logrus.Logger
-	repo Repository
+	log          *logrus.Logger
+	repo         Repository
+	currencyRepo currency.Repository
 }
 
-func NewUsecase(log *logrus.Logger, repo Repository) Usecase {
+func NewUsecase(log *logrus.Logger, repo Repository, currencyRepo currency.Repository) Usecase {
 	return &usecase{
 		log,
 		repo,
+		currencyRepo,
 	}
 }
 
 func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.Response) {
 	db := config.GetDatabase()
+
+	if req.Currency == "" {
+		base, err := u.currencyRepo.GetBaseCurrency(user.ID, db)
+		if err != nil {
+			u.log.Errorf("currencyRepo.GetBaseCurrency: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		req.Currency = base
+	}
+
 	tx, err := db.Beginx()
 	if err != nil {
 		u.log.Errorf("error begin tx: %s", err.Error())
@@ -43,13 +61,13 @@ func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.
 	}
 	defer tx.Rollback()
 
-	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), fmt.Sprintf("%0.f", req.Value))
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
 	if err != nil {
 		u.log.Errorf("error encrypting value: %s", err.Error())
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 	}
 
-	encAmount, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), fmt.Sprintf("%0.f", req.Amount))
+	encAmount, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Amount.String())
 	if err != nil {
 		u.log.Errorf("error encrypting amount: %s", err.Error())
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
@@ -59,6 +77,7 @@ func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.
 		CategoryId: req.CategoryId,
 		Value:      encValue,
 		Amount:     encAmount,
+		Currency:   req.Currency,
 		UserId:     user.ID,
 		Notes:      req.Notes,
 	}
@@ -107,7 +126,7 @@ func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp commo
 			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 		}
 
-		value, err := strconv.ParseFloat(decValue, 64)
+		value, err := libs.ParseMoney(decValue)
 		if err != nil {
 			u.log.Errorf("error parsing string: %s", err.Error())
 			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
@@ -119,7 +138,7 @@ func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp commo
 			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 		}
 
-		amount, err := strconv.ParseFloat(decAmount, 64)
+		amount, err := libs.ParseMoney(decAmount)
 		if err != nil {
 			u.log.Errorf("error parsing string: %s", err.Error())
 			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
@@ -131,6 +150,7 @@ func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp commo
 			Category:   data.Category,
 			Amount:     amount,
 			Value:      value,
+			Currency:   data.Currency,
 			Notes:      data.Notes,
 		}
 	}
@@ -145,6 +165,7 @@ func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp commo
 
 func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response) {
 	db := config.GetDatabase()
+
 	tx, err := db.Beginx()
 	if err != nil {
 		u.log.Errorf("error begin tx: %s", err.Error())
@@ -152,13 +173,13 @@ func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp c
 	}
 	defer tx.Rollback()
 
-	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), fmt.Sprintf("%0.f", req.Value))
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
 	if err != nil {
 		u.log.Errorf("error encrypting value: %s", err.Error())
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 	}
 
-	encAmount, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), fmt.Sprintf("%0.f", req.Amount))
+	encAmount, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Amount.String())
 	if err != nil {
 		u.log.Errorf("error encrypting amount: %s", err.Error())
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
@@ -171,6 +192,11 @@ func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp c
 		"notes":       req.Notes,
 	}
 
+	// a client that leaves currency out keeps the stored one
+	if req.Currency != "" {
+		data["currency"] = req.Currency
+	}
+
 	err = u.repo.Update(user.ID, req.ID, data, tx)
 	if err != nil {
 		u.log.Errorf("failed update asset: %s", err.Error())
@@ -208,3 +234,132 @@ func (u *usecase) Delete(user *userModel.User, id uint) (resp common.Response) {
 
 	return resp.CustomResponse(http.StatusOK, "success", nil)
 }
+
+func (u *usecase) AddCategory(user *userModel.User, req *model.CategoryRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	data := model.AssetCategory{
+		Name:   req.Name,
+		UserId: user.ID,
+	}
+
+	err = u.repo.InsertCategory(&data, tx)
+	if err != nil {
+		u.log.Errorf("failed insert asset category: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", nil)
+}
+
+func (u *usecase) UpdateCategory(user *userModel.User, req *model.CategoryRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	_, err := u.repo.GetCategoryById(user.ID, req.ID, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "category not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetCategoryById: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	data := map[string]any{
+		"name": req.Name,
+	}
+
+	err = u.repo.UpdateCategory(user.ID, req.ID, data, tx)
+	if err != nil {
+		u.log.Errorf("failed update asset category: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) DeleteCategory(user *userModel.User, req *model.DeleteCategoryRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	_, err := u.repo.GetCategoryById(user.ID, req.ID, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "category not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetCategoryById: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if req.ReassignTo != 0 {
+		if req.ReassignTo == req.ID {
+			return resp.CustomResponse(http.StatusBadRequest, "cannot reassign transactions to the deleted category", nil)
+		}
+
+		_, err := u.repo.GetCategoryById(user.ID, req.ReassignTo, db)
+		if err != nil && errors.Is(err, sql.ErrNoRows) {
+			return resp.CustomResponse(http.StatusNotFound, "reassign target category not found", nil)
+		} else if err != nil {
+			u.log.Errorf("repo.GetCategoryById: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	if req.ReassignTo != 0 {
+		err = u.repo.ReassignCategory(user.ID, req.ID, req.ReassignTo, tx)
+		if err != nil {
+			u.log.Errorf("failed reassign asset category: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	} else {
+		total, err := u.repo.CountByCategory(user.ID, req.ID, tx)
+		if err != nil {
+			u.log.Errorf("repo.CountByCategory: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		if total > 0 {
+			message := fmt.Sprintf("category is still used by %d asset(s), reassign them to another category before deleting", total)
+			return resp.CustomResponse(http.StatusBadRequest, message, nil)
+		}
+	}
+
+	err = u.repo.DeleteCategory(user.ID, req.ID, tx)
+	if err != nil {
+		u.log.Errorf("failed delete asset category: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
@@ -0,0 +1,69 @@
+package balancesheet
+
+import (
+	"net/http"
+
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/balance_sheet/model"
+	"github.com/fazriegi/money_management-be/module/common"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type Controller interface {
+	Get(ctx *fiber.Ctx) error
+	History(ctx *fiber.Ctx) error
+}
+
+type controller struct {
+	log     *logrus.Logger
+	usecase Usecase
+}
+
+func NewController(log *logrus.Logger, usecase Usecase) Controller {
+	return &controller{
+		log,
+		usecase,
+	}
+}
+
+func (c *controller) Get(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		user     = ctx.Locals("user").(userModel.User)
+	)
+
+	response = c.usecase.Get(&user)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
+
+func (c *controller) History(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.HistoryRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	if err := ctx.QueryParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing query param: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseQueryParamErr, nil))
+	}
+
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
+	response = c.usecase.History(&user, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
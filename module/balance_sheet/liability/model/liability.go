@@ -0,0 +1,53 @@
+package model
+
+import (
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/common"
+)
+
+type Liability struct {
+	ID       uint        `db:"id"`
+	Name     string      `db:"name"`
+	Date     interface{} `db:"date"`
+	Value    string      `db:"value"`
+	Currency string      `db:"currency"`
+	UserId   uint        `db:"user_id"`
+}
+
+type AddRequest struct {
+	Name     string      `json:"name" validate:"required"`
+	Date     interface{} `json:"date" validate:"required"`
+	Value    libs.Money  `json:"value" validate:"required"`
+	Currency string      `json:"currency" validate:"omitempty,iso4217"`
+}
+
+type ListRequest struct {
+	common.PaginationRequest
+	Keyword string `query:"keyword"`
+	UserId  uint
+}
+
+type GetLiability struct {
+	ID       uint        `db:"id"`
+	Name     string      `db:"name"`
+	Date     interface{} `db:"date"`
+	Value    string      `db:"value"`
+	Currency string      `db:"currency"`
+	UserId   uint        `db:"user_id"`
+}
+
+type ListResponse struct {
+	ID       uint        `json:"id"`
+	Name     string      `json:"name"`
+	Date     interface{} `json:"date"`
+	Value    libs.Money  `json:"value"`
+	Currency string      `json:"currency"`
+}
+
+type UpdateRequest struct {
+	ID       uint
+	Name     string      `json:"name" validate:"required"`
+	Date     interface{} `json:"date" validate:"required"`
+	Value    libs.Money  `json:"value" validate:"required"`
+	Currency string      `json:"currency" validate:"omitempty,iso4217"`
+}
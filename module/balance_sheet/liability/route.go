@@ -0,0 +1,23 @@
+package liability
+
+import (
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/middleware"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/gofiber/fiber/v2"
+)
+
+func NewRoute(app *fiber.App, jwt *libs.JWT) {
+	log := config.GetLogger()
+	repo := NewRepository()
+	currencyRepo := currency.NewRepository()
+	usecase := NewUsecase(log, repo, currencyRepo)
+	controller := NewController(log, usecase)
+
+	route := app.Group("/liability")
+	route.Post("/", middleware.Authentication(jwt), controller.Add)
+	route.Get("/", middleware.Authentication(jwt), controller.List)
+	route.Put("/:id", middleware.Authentication(jwt), controller.Update)
+	route.Delete("/:id", middleware.Authentication(jwt), controller.Delete)
+}
@@ -0,0 +1,190 @@
+package liability
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/balance_sheet/liability/model"
+	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/sirupsen/logrus"
+)
+
+type Usecase interface {
+	Add(user *userModel.User, req *model.AddRequest) (resp common.Response)
+	List(user *userModel.User, req *model.ListRequest) (resp common.Response)
+	Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response)
+	Delete(user *userModel.User, id uint) (resp common.Response)
+}
+
+type usecase struct {
+	log          *logrus.Logger
+	repo         Repository
+	currencyRepo currency.Repository
+}
+
+func NewUsecase(log *logrus.Logger, repo Repository, currencyRepo currency.Repository) Usecase {
+	return &usecase{
+		log,
+		repo,
+		currencyRepo,
+	}
+}
+
+func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	if req.Currency == "" {
+		base, err := u.currencyRepo.GetBaseCurrency(user.ID, db)
+		if err != nil {
+			u.log.Errorf("currencyRepo.GetBaseCurrency: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		req.Currency = base
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	data := model.Liability{
+		Name:     req.Name,
+		Date:     req.Date,
+		Value:    encValue,
+		Currency: req.Currency,
+		UserId:   user.ID,
+	}
+
+	err = u.repo.Insert(&data, tx)
+	if err != nil {
+		u.log.Errorf("failed insert liability: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", nil)
+}
+
+func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	req.UserId = user.ID
+	listData, total, err := u.repo.List(req, db)
+	if err != nil {
+		u.log.Errorf("repo.List: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	result := make([]model.ListResponse, len(listData))
+	for i, data := range listData {
+		decValue, err := libs.Decrypt(fmt.Sprintf("%d", user.ID), data.Value)
+		if err != nil {
+			u.log.Errorf("error decrypting value: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		value, err := libs.ParseMoney(decValue)
+		if err != nil {
+			u.log.Errorf("error parsing string: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		result[i] = model.ListResponse{
+			ID:       data.ID,
+			Name:     data.Name,
+			Date:     data.Date,
+			Value:    value,
+			Currency: data.Currency,
+		}
+	}
+
+	responseData := map[string]any{
+		"data":  result,
+		"total": total,
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", responseData)
+}
+
+func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	data := map[string]any{
+		"name":  req.Name,
+		"date":  req.Date,
+		"value": encValue,
+	}
+
+	// a client that leaves currency out keeps the stored one
+	if req.Currency != "" {
+		data["currency"] = req.Currency
+	}
+
+	err = u.repo.Update(user.ID, req.ID, data, tx)
+	if err != nil {
+		u.log.Errorf("failed update liability: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) Delete(user *userModel.User, id uint) (resp common.Response) {
+	db := config.GetDatabase()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	err = u.repo.Delete(user.ID, id, tx)
+	if err != nil {
+		u.log.Errorf("failed delete liability: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
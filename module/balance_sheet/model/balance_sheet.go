@@ -0,0 +1,87 @@
+package model
+
+import (
+	"github.com/fazriegi/money_management-be/libs"
+	"time"
+)
+
+type AssetSummary struct {
+	CategoryId uint       `json:"category_id"`
+	Category   string     `json:"category"`
+	Value      libs.Money `json:"value"`
+}
+
+type LiabilitySummary struct {
+	ID    uint        `json:"id"`
+	Name  string      `json:"name"`
+	Date  interface{} `json:"date"`
+	Value libs.Money  `json:"value"`
+}
+
+type BalanceSheet struct {
+	Assets         []AssetSummary     `json:"assets"`
+	Liabilities    []LiabilitySummary `json:"liabilities"`
+	TotalAsset     libs.Money         `json:"total_asset"`
+	TotalLiability libs.Money         `json:"total_liability"`
+	NetWorth       libs.Money         `json:"net_worth"`
+	Currency       string             `json:"currency"`
+}
+
+type Snapshot struct {
+	ID             uint   `db:"id"`
+	StartDate      string `db:"start_date"`
+	EndDate        string `db:"end_date"`
+	TotalAsset     string `db:"total_asset"`
+	TotalLiability string `db:"total_liability"`
+	NetWorth       string `db:"net_worth"`
+	Currency       string `db:"currency"`
+	UserId         uint   `db:"user_id"`
+}
+
+type SnapshotDetail struct {
+	ID          uint   `db:"id"`
+	SnapshotId  uint   `db:"snapshot_id"`
+	Type        string `db:"type"`
+	ReferenceId uint   `db:"reference_id"`
+	Name        string `db:"name"`
+	Value       string `db:"value"`
+}
+
+type GetSnapshot struct {
+	ID             uint      `db:"id"`
+	StartDate      time.Time `db:"start_date"`
+	EndDate        time.Time `db:"end_date"`
+	TotalAsset     string    `db:"total_asset"`
+	TotalLiability string    `db:"total_liability"`
+	NetWorth       string    `db:"net_worth"`
+	Currency       string    `db:"currency"`
+	TakenAt        time.Time `db:"created_at"`
+}
+
+// HistoryRequest filters snapshots by the end of their period, each bound is
+// optional
+type HistoryRequest struct {
+	StartDate string `query:"start_date" validate:"omitempty,datetime=2006-01-02"`
+	EndDate   string `query:"end_date" validate:"omitempty,datetime=2006-01-02"`
+	UserId    uint
+}
+
+type SnapshotItem struct {
+	ID    uint       `json:"id"`
+	Name  string     `json:"name"`
+	Value libs.Money `json:"value"`
+}
+
+// SnapshotData holds the balances as they were at TakenAt. A snapshot caught
+// up after downtime is taken later than the end of its period.
+type SnapshotData struct {
+	StartDate      time.Time      `json:"start_date"`
+	EndDate        time.Time      `json:"end_date"`
+	TakenAt        time.Time      `json:"taken_at"`
+	Assets         []SnapshotItem `json:"assets"`
+	Liabilities    []SnapshotItem `json:"liabilities"`
+	TotalAsset     libs.Money     `json:"total_asset"`
+	TotalLiability libs.Money     `json:"total_liability"`
+	NetWorth       libs.Money     `json:"net_worth"`
+	Currency       string         `json:"currency"`
+}
@@ -0,0 +1,154 @@
+package balancesheet
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/balance_sheet/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	IsSnapshotExist(userId uint, startDate string, db *sqlx.DB) (bool, error)
+	InsertSnapshot(data *model.Snapshot, tx *sqlx.Tx) (result uint, err error)
+	InsertSnapshotDetail(data []model.SnapshotDetail, tx *sqlx.Tx) error
+	ListSnapshot(req *model.HistoryRequest, db *sqlx.DB) (result []model.GetSnapshot, err error)
+	ListSnapshotDetail(snapshotIds []uint, db *sqlx.DB) (result []model.SnapshotDetail, err error)
+}
+
+type repository struct{}
+
+func NewRepository() Repository {
+	return &repository{}
+}
+
+func (r *repository) IsSnapshotExist(userId uint, startDate string, db *sqlx.DB) (bool, error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("balance_sheet_snapshot").
+		Select(goqu.COUNT("*")).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("start_date").Eq(startDate),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return false, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	var total uint
+	if err := db.Get(&total, sql, val...); err != nil {
+		return false, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return total > 0, nil
+}
+
+func (r *repository) InsertSnapshot(data *model.Snapshot, tx *sqlx.Tx) (result uint, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("balance_sheet_snapshot").Rows(*data)
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	res, err := tx.Exec(sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return result, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return uint(id), nil
+}
+
+func (r *repository) InsertSnapshotDetail(data []model.SnapshotDetail, tx *sqlx.Tx) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("balance_sheet_snapshot_detail").Rows(data)
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) ListSnapshot(req *model.HistoryRequest, db *sqlx.DB) (result []model.GetSnapshot, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("balance_sheet_snapshot").
+		Select(
+			goqu.I("id"),
+			goqu.I("start_date"),
+			goqu.I("end_date"),
+			goqu.I("total_asset"),
+			goqu.I("total_liability"),
+			goqu.I("net_worth"),
+			goqu.I("currency"),
+			goqu.I("created_at"),
+		).
+		Where(goqu.I("user_id").Eq(req.UserId)).
+		Order(goqu.I("start_date").Asc())
+
+	if req.StartDate != "" {
+		dataset = dataset.Where(goqu.I("end_date").Gte(req.StartDate))
+	}
+
+	if req.EndDate != "" {
+		dataset = dataset.Where(goqu.I("end_date").Lte(req.EndDate))
+	}
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.GetSnapshot, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) ListSnapshotDetail(snapshotIds []uint, db *sqlx.DB) (result []model.SnapshotDetail, err error) {
+	result = make([]model.SnapshotDetail, 0)
+	if len(snapshotIds) == 0 {
+		return
+	}
+
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("balance_sheet_snapshot_detail").
+		Where(goqu.I("snapshot_id").In(snapshotIds)).
+		Order(goqu.I("name").Asc())
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
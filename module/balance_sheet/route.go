@@ -1,11 +1,39 @@
 package balancesheet
 
 import (
+	"github.com/fazriegi/money_management-be/config"
 	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/middleware"
 	"github.com/fazriegi/money_management-be/module/balance_sheet/asset"
+	"github.com/fazriegi/money_management-be/module/balance_sheet/liability"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
 	"github.com/gofiber/fiber/v2"
 )
 
 func NewRoute(app *fiber.App, jwt *libs.JWT) {
 	asset.NewRoute(app, jwt)
+	liability.NewRoute(app, jwt)
+	balanceSheetRoute(app, jwt)
+}
+
+func balanceSheetRoute(app *fiber.App, jwt *libs.JWT) {
+	log := config.GetLogger()
+	usecase := newUsecase()
+	controller := NewController(log, usecase)
+
+	route := app.Group("/balance-sheet")
+	route.Get("/", middleware.Authentication(jwt), controller.Get)
+	route.Get("/history", middleware.Authentication(jwt), controller.History)
+}
+
+func newUsecase() Usecase {
+	log := config.GetLogger()
+	repo := NewRepository()
+	assetRepo := asset.NewRepository()
+	liabilityRepo := liability.NewRepository()
+	periodRepo := period.NewRepository()
+	currencyRepo := currency.NewRepository()
+
+	return NewUsecase(log, repo, assetRepo, liabilityRepo, periodRepo, currencyRepo)
 }
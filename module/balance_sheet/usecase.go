@@ -0,0 +1,383 @@
+package balancesheet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/balance_sheet/asset"
+	assetModel "github.com/fazriegi/money_management-be/module/balance_sheet/asset/model"
+	"github.com/fazriegi/money_management-be/module/balance_sheet/liability"
+	liabilityModel "github.com/fazriegi/money_management-be/module/balance_sheet/liability/model"
+	"github.com/fazriegi/money_management-be/module/balance_sheet/model"
+	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	periodModel "github.com/fazriegi/money_management-be/module/master/period/model"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	snapshotTypeAsset     = "asset"
+	snapshotTypeLiability = "liability"
+)
+
+type Usecase interface {
+	Get(user *userModel.User) (resp common.Response)
+	History(user *userModel.User, req *model.HistoryRequest) (resp common.Response)
+	TakeSnapshot()
+}
+
+type usecase struct {
+	log           *logrus.Logger
+	repo          Repository
+	assetRepo     asset.Repository
+	liabilityRepo liability.Repository
+	periodRepo    period.Repository
+	currencyRepo  currency.Repository
+}
+
+func NewUsecase(log *logrus.Logger, repo Repository, assetRepo asset.Repository, liabilityRepo liability.Repository, periodRepo period.Repository, currencyRepo currency.Repository) Usecase {
+	return &usecase{
+		log,
+		repo,
+		assetRepo,
+		liabilityRepo,
+		periodRepo,
+		currencyRepo,
+	}
+}
+
+func (u *usecase) Get(user *userModel.User) (resp common.Response) {
+	db := config.GetDatabase()
+
+	result, err := u.calculate(user.ID, db)
+	if err != nil && errors.Is(err, currency.ErrRateNotFound) {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	} else if err != nil {
+		u.log.Errorf("failed calculate balance sheet: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+// calculate builds the balance sheet from the current asset and liability
+// values of a user in the base currency. Assets are valued at today's rate and
+// liabilities at the rate of their date.
+func (u *usecase) calculate(userId uint, db *sqlx.DB) (result model.BalanceSheet, err error) {
+	var (
+		assets      []assetModel.GetAsset
+		liabilities []liabilityModel.GetLiability
+		converter   *currency.Converter
+		key         = fmt.Sprintf("%d", userId)
+		now         = time.Now()
+	)
+
+	g, _ := errgroup.WithContext(context.Background())
+
+	g.Go(func() error {
+		c, err := currency.NewConverter(userId, u.currencyRepo, db)
+		if err != nil {
+			return fmt.Errorf("currency.NewConverter: %w", err)
+		}
+
+		converter = c
+		return nil
+	})
+
+	g.Go(func() error {
+		data, _, err := u.assetRepo.List(&assetModel.ListRequest{UserId: userId}, db)
+		if err != nil {
+			return fmt.Errorf("assetRepo.List: %w", err)
+		}
+
+		assets = data
+		return nil
+	})
+
+	g.Go(func() error {
+		data, _, err := u.liabilityRepo.List(&liabilityModel.ListRequest{UserId: userId}, db)
+		if err != nil {
+			return fmt.Errorf("liabilityRepo.List: %w", err)
+		}
+
+		liabilities = data
+		return nil
+	})
+
+	if err = g.Wait(); err != nil {
+		return
+	}
+
+	result.Currency = converter.Base()
+
+	assetByCategory := make(map[uint]*model.AssetSummary)
+	for _, data := range assets {
+		value, err := decryptMoney(key, data.Value)
+		if err != nil {
+			return result, fmt.Errorf("error decrypting asset value: %w", err)
+		}
+
+		value, err = converter.Convert(value, data.Currency, now)
+		if err != nil {
+			return result, err
+		}
+
+		summary, ok := assetByCategory[data.CategoryId]
+		if !ok {
+			summary = &model.AssetSummary{
+				CategoryId: data.CategoryId,
+				Category:   data.Category,
+			}
+			assetByCategory[data.CategoryId] = summary
+		}
+
+		summary.Value = summary.Value.Add(value)
+		result.TotalAsset = result.TotalAsset.Add(value)
+	}
+
+	result.Assets = make([]model.AssetSummary, 0, len(assetByCategory))
+	for _, summary := range assetByCategory {
+		result.Assets = append(result.Assets, *summary)
+	}
+
+	sort.Slice(result.Assets, func(i, j int) bool {
+		return result.Assets[i].Category < result.Assets[j].Category
+	})
+
+	result.Liabilities = make([]model.LiabilitySummary, len(liabilities))
+	for i, data := range liabilities {
+		value, err := decryptMoney(key, data.Value)
+		if err != nil {
+			return result, fmt.Errorf("error decrypting liability value: %w", err)
+		}
+
+		value, err = converter.Convert(value, data.Currency, libs.ParseDate(data.Date))
+		if err != nil {
+			return result, err
+		}
+
+		result.Liabilities[i] = model.LiabilitySummary{
+			ID:    data.ID,
+			Name:  data.Name,
+			Date:  data.Date,
+			Value: value,
+		}
+		result.TotalLiability = result.TotalLiability.Add(value)
+	}
+
+	result.NetWorth = result.TotalAsset.Sub(result.TotalLiability)
+
+	return
+}
+
+func (u *usecase) History(user *userModel.User, req *model.HistoryRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	key := fmt.Sprintf("%d", user.ID)
+
+	req.UserId = user.ID
+	snapshots, err := u.repo.ListSnapshot(req, db)
+	if err != nil {
+		u.log.Errorf("repo.ListSnapshot: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	snapshotIds := make([]uint, len(snapshots))
+	for i, snapshot := range snapshots {
+		snapshotIds[i] = snapshot.ID
+	}
+
+	details, err := u.repo.ListSnapshotDetail(snapshotIds, db)
+	if err != nil {
+		u.log.Errorf("repo.ListSnapshotDetail: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	result := make([]model.SnapshotData, len(snapshots))
+	indexById := make(map[uint]int, len(snapshots))
+	for i, snapshot := range snapshots {
+		data := model.SnapshotData{
+			StartDate:   snapshot.StartDate,
+			EndDate:     snapshot.EndDate,
+			TakenAt:     snapshot.TakenAt,
+			Currency:    snapshot.Currency,
+			Assets:      make([]model.SnapshotItem, 0),
+			Liabilities: make([]model.SnapshotItem, 0),
+		}
+
+		for _, v := range []struct {
+			dest   *libs.Money
+			cipher string
+		}{
+			{&data.TotalAsset, snapshot.TotalAsset},
+			{&data.TotalLiability, snapshot.TotalLiability},
+			{&data.NetWorth, snapshot.NetWorth},
+		} {
+			if *v.dest, err = decryptMoney(key, v.cipher); err != nil {
+				u.log.Errorf("error decrypting snapshot value: %s", err.Error())
+				return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+			}
+		}
+
+		result[i] = data
+		indexById[snapshot.ID] = i
+	}
+
+	for _, detail := range details {
+		value, err := decryptMoney(key, detail.Value)
+		if err != nil {
+			u.log.Errorf("error decrypting snapshot detail value: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		item := model.SnapshotItem{
+			ID:    detail.ReferenceId,
+			Name:  detail.Name,
+			Value: value,
+		}
+
+		data := &result[indexById[detail.SnapshotId]]
+		if detail.Type == snapshotTypeAsset {
+			data.Assets = append(data.Assets, item)
+		} else {
+			data.Liabilities = append(data.Liabilities, item)
+		}
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+// TakeSnapshot stores the balance sheet of every user whose last period has
+// closed and has no snapshot yet. Values are taken as they are when the job
+// runs, so a snapshot caught up after downtime reflects the catch-up time,
+// which History shows as its taken_at.
+func (u *usecase) TakeSnapshot() {
+	db := config.GetDatabase()
+
+	periods, err := u.periodRepo.ListPeriod(db)
+	if err != nil {
+		u.log.Errorf("periodRepo.ListPeriod: %s", err.Error())
+		return
+	}
+
+	now := time.Now()
+	for _, p := range periods {
+		closed := period.Shift(p.DayOfMonth, period.GetRangeOf(p.DayOfMonth, now), -1)
+
+		if err := u.takeSnapshot(p.UserId, closed, db); err != nil {
+			u.log.Errorf("failed take balance sheet snapshot of user %d: %s", p.UserId, err.Error())
+		}
+	}
+}
+
+func (u *usecase) takeSnapshot(userId uint, periodRange periodModel.PeriodRange, db *sqlx.DB) error {
+	startDate := periodRange.StartDate.Format(constant.DateFormat)
+
+	isExist, err := u.repo.IsSnapshotExist(userId, startDate, db)
+	if err != nil {
+		return fmt.Errorf("repo.IsSnapshotExist: %w", err)
+	}
+
+	if isExist {
+		return nil
+	}
+
+	sheet, err := u.calculate(userId, db)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%d", userId)
+	snapshot := model.Snapshot{
+		StartDate: startDate,
+		EndDate:   periodRange.EndDate.Format(constant.DateFormat),
+		Currency:  sheet.Currency,
+		UserId:    userId,
+	}
+
+	for _, v := range []struct {
+		dest  *string
+		value libs.Money
+	}{
+		{&snapshot.TotalAsset, sheet.TotalAsset},
+		{&snapshot.TotalLiability, sheet.TotalLiability},
+		{&snapshot.NetWorth, sheet.NetWorth},
+	} {
+		if *v.dest, err = libs.Encrypt(key, v.value.String()); err != nil {
+			return fmt.Errorf("error encrypting snapshot value: %w", err)
+		}
+	}
+
+	details := make([]model.SnapshotDetail, 0, len(sheet.Assets)+len(sheet.Liabilities))
+	for _, v := range sheet.Assets {
+		encValue, err := libs.Encrypt(key, v.Value.String())
+		if err != nil {
+			return fmt.Errorf("error encrypting snapshot value: %w", err)
+		}
+
+		details = append(details, model.SnapshotDetail{
+			Type:        snapshotTypeAsset,
+			ReferenceId: v.CategoryId,
+			Name:        v.Category,
+			Value:       encValue,
+		})
+	}
+
+	for _, v := range sheet.Liabilities {
+		encValue, err := libs.Encrypt(key, v.Value.String())
+		if err != nil {
+			return fmt.Errorf("error encrypting snapshot value: %w", err)
+		}
+
+		details = append(details, model.SnapshotDetail{
+			Type:        snapshotTypeLiability,
+			ReferenceId: v.ID,
+			Name:        v.Name,
+			Value:       encValue,
+		})
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	snapshotId, err := u.repo.InsertSnapshot(&snapshot, tx)
+	if err != nil {
+		return fmt.Errorf("repo.InsertSnapshot: %w", err)
+	}
+
+	for i := range details {
+		details[i].SnapshotId = snapshotId
+	}
+
+	if err := u.repo.InsertSnapshotDetail(details, tx); err != nil {
+		return fmt.Errorf("repo.InsertSnapshotDetail: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func decryptMoney(key, cipher string) (libs.Money, error) {
+	decValue, err := libs.Decrypt(key, cipher)
+	if err != nil {
+		return libs.Money{}, err
+	}
+
+	return libs.ParseMoney(decValue)
+}
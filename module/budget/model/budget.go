@@ -0,0 +1,56 @@
+package model
+
+import (
+	"github.com/fazriegi/money_management-be/libs"
+	periodModel "github.com/fazriegi/money_management-be/module/master/period/model"
+)
+
+type Budget struct {
+	ID         uint   `db:"id"`
+	CategoryId uint   `db:"category_id"`
+	Period     string `db:"period"`
+	Value      string `db:"value"`
+	UserId     uint   `db:"user_id"`
+}
+
+type GetBudget struct {
+	ID         uint   `db:"id"`
+	CategoryId uint   `db:"category_id"`
+	Category   string `db:"category"`
+	Period     string `db:"period"`
+	Value      string `db:"value"`
+}
+
+type AddRequest struct {
+	CategoryId uint       `json:"category_id" validate:"required"`
+	Period     string     `json:"period"`
+	Value      libs.Money `json:"value" validate:"required,gt=0"`
+}
+
+type UpdateRequest struct {
+	ID    uint
+	Value libs.Money `json:"value" validate:"required,gt=0"`
+}
+
+type ListRequest struct {
+	Period string `query:"period"`
+	UserId uint
+}
+
+type BudgetData struct {
+	ID         uint       `json:"id"`
+	CategoryId uint       `json:"category_id"`
+	Category   string     `json:"category"`
+	Limit      libs.Money `json:"limit"`
+	Spent      libs.Money `json:"spent"`
+	Remaining  libs.Money `json:"remaining"`
+	Percentage float64    `json:"percentage"`
+}
+
+type ListResponse struct {
+	Period     periodModel.PeriodRange `json:"period"`
+	Data       []BudgetData            `json:"data"`
+	TotalLimit libs.Money              `json:"total_limit"`
+	TotalSpent libs.Money              `json:"total_spent"`
+	Currency   string                  `json:"currency"`
+}
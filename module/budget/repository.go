@@ -0,0 +1,145 @@
+package budget
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/budget/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Insert(data *model.Budget, tx *sqlx.Tx) error
+	IsExist(userId, categoryId uint, period string, db *sqlx.DB) (bool, error)
+	List(req *model.ListRequest, db *sqlx.DB) (result []model.GetBudget, err error)
+	Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error
+	Delete(userId, id uint, tx *sqlx.Tx) error
+}
+
+type repository struct{}
+
+func NewRepository() Repository {
+	return &repository{}
+}
+
+func (r *repository) Insert(data *model.Budget, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("budget").Rows(*data)
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) IsExist(userId, categoryId uint, period string, db *sqlx.DB) (bool, error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("budget").
+		Select(goqu.COUNT("*")).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("category_id").Eq(categoryId),
+			goqu.I("period").Eq(period),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return false, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	var total uint
+	if err := db.Get(&total, sql, val...); err != nil {
+		return false, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return total > 0, nil
+}
+
+func (r *repository) List(req *model.ListRequest, db *sqlx.DB) (result []model.GetBudget, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.
+		From("budget").
+		Join(goqu.T("user_expense_category").As("uec"), goqu.On(
+			goqu.I("uec.id").Eq(goqu.I("budget.category_id")),
+			goqu.I("uec.user_id").Eq(goqu.I("budget.user_id")),
+		)).
+		Select(
+			goqu.I("budget.id"),
+			goqu.I("budget.category_id"),
+			goqu.I("uec.name").As("category"),
+			goqu.I("budget.period"),
+			goqu.I("budget.value"),
+		).
+		Where(
+			goqu.I("budget.user_id").Eq(req.UserId),
+			goqu.I("budget.period").Eq(req.Period),
+		).
+		Order(goqu.I("uec.name").Asc())
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.GetBudget, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("budget").Set(data).
+		Where(
+			goqu.I("id").Eq(id),
+			goqu.I("user_id").Eq(userId),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(userId, id uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Delete("budget").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	return nil
+}
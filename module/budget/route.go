@@ -0,0 +1,27 @@
+package budget
+
+import (
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/middleware"
+	"github.com/fazriegi/money_management-be/module/cashflow/expense"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	"github.com/gofiber/fiber/v2"
+)
+
+func NewRoute(app *fiber.App, jwt *libs.JWT) {
+	log := config.GetLogger()
+	repo := NewRepository()
+	expenseRepo := expense.NewRepository()
+	periodRepo := period.NewRepository()
+	currencyRepo := currency.NewRepository()
+	usecase := NewUsecase(log, repo, expenseRepo, periodRepo, currencyRepo)
+	controller := NewController(log, usecase)
+
+	route := app.Group("/budget")
+	route.Post("/", middleware.Authentication(jwt), controller.Add)
+	route.Get("/", middleware.Authentication(jwt), controller.List)
+	route.Put("/:id", middleware.Authentication(jwt), controller.Update)
+	route.Delete("/:id", middleware.Authentication(jwt), controller.Delete)
+}
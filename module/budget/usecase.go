@@ -0,0 +1,314 @@
+package budget
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/budget/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/expense"
+	expenseModel "github.com/fazriegi/money_management-be/module/cashflow/expense/model"
+	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/sirupsen/logrus"
+)
+
+type Usecase interface {
+	Add(user *userModel.User, req *model.AddRequest) (resp common.Response)
+	List(user *userModel.User, req *model.ListRequest) (resp common.Response)
+	Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response)
+	Delete(user *userModel.User, id uint) (resp common.Response)
+}
+
+type usecase struct {
+	log          *logrus.Logger
+	repo         Repository
+	expenseRepo  expense.Repository
+	periodRepo   period.Repository
+	currencyRepo currency.Repository
+}
+
+func NewUsecase(log *logrus.Logger, repo Repository, expenseRepo expense.Repository, periodRepo period.Repository, currencyRepo currency.Repository) Usecase {
+	return &usecase{
+		log,
+		repo,
+		expenseRepo,
+		periodRepo,
+		currencyRepo,
+	}
+}
+
+func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	if req.Period == "" {
+		req.Period = period.PeriodCurrent
+	}
+
+	periodRange, err := period.ResolveRange(u.periodRepo, user.ID, req.Period, db)
+	if err != nil && errors.Is(err, period.ErrInvalidPeriod) {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	} else if err != nil {
+		u.log.Errorf("period.ResolveRange: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	_, err = u.expenseRepo.GetCategoryById(user.ID, req.CategoryId, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "category not found", nil)
+	} else if err != nil {
+		u.log.Errorf("expenseRepo.GetCategoryById: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	isExist, err := u.repo.IsExist(user.ID, req.CategoryId, periodRange.Period, db)
+	if err != nil {
+		u.log.Errorf("repo.IsExist: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if isExist {
+		return resp.CustomResponse(http.StatusBadRequest, "budget for this category and period already exists", nil)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	data := model.Budget{
+		CategoryId: req.CategoryId,
+		Period:     periodRange.Period,
+		Value:      encValue,
+		UserId:     user.ID,
+	}
+
+	err = u.repo.Insert(&data, tx)
+	if err != nil {
+		u.log.Errorf("failed insert budget: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", nil)
+}
+
+func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	key := fmt.Sprintf("%d", user.ID)
+
+	if req.Period == "" {
+		req.Period = period.PeriodCurrent
+	}
+
+	periodRange, err := period.ResolveRange(u.periodRepo, user.ID, req.Period, db)
+	if err != nil && errors.Is(err, period.ErrInvalidPeriod) {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	} else if err != nil {
+		u.log.Errorf("period.ResolveRange: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	req.UserId = user.ID
+	req.Period = periodRange.Period
+	budgets, err := u.repo.List(req, db)
+	if err != nil {
+		u.log.Errorf("repo.List: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	expenses, err := u.expenseRepo.List(&expenseModel.ListRequest{
+		UserId:    user.ID,
+		StartDate: periodRange.StartDate.Format(constant.DateFormat),
+		EndDate:   periodRange.EndDate.Format(constant.DateFormat),
+	}, db)
+	if err != nil {
+		u.log.Errorf("expenseRepo.List: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	categories, err := u.expenseRepo.ListCategory(user.ID, db)
+	if err != nil {
+		u.log.Errorf("expenseRepo.ListCategory: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	// limits are set in the base currency, so spending is converted to it
+	converter, err := currency.NewConverter(user.ID, u.currencyRepo, db)
+	if err != nil {
+		u.log.Errorf("currency.NewConverter: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	spentByCategory := make(map[uint]libs.Money)
+	for _, data := range expenses {
+		decValue, err := libs.Decrypt(key, data.Value)
+		if err != nil {
+			u.log.Errorf("error decrypting value: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		value, err := libs.ParseMoney(decValue)
+		if err != nil {
+			u.log.Errorf("error parsing string: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		value, err = converter.Convert(value, data.Currency, libs.ParseDate(data.Date))
+		if err != nil {
+			return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+		}
+
+		spentByCategory[data.CategoryId] = spentByCategory[data.CategoryId].Add(value)
+	}
+
+	parents := make(map[uint]uint, len(categories))
+	for _, category := range categories {
+		if category.ParentId != nil {
+			parents[category.ID] = *category.ParentId
+		}
+	}
+
+	budgeted := make(map[uint]struct{}, len(budgets))
+	for _, data := range budgets {
+		budgeted[data.CategoryId] = struct{}{}
+	}
+
+	result := model.ListResponse{
+		Period:   periodRange,
+		Currency: converter.Base(),
+		Data:     make([]model.BudgetData, len(budgets)),
+	}
+
+	for i, data := range budgets {
+		decValue, err := libs.Decrypt(key, data.Value)
+		if err != nil {
+			u.log.Errorf("error decrypting value: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		limit, err := libs.ParseMoney(decValue)
+		if err != nil {
+			u.log.Errorf("error parsing string: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		// spending in sub-categories counts toward the budget of their parent
+		var spent libs.Money
+		for _, categoryId := range libs.Descendants(data.CategoryId, parents) {
+			spent = spent.Add(spentByCategory[categoryId])
+		}
+
+		var percentage float64
+		if limit.Sign() > 0 {
+			percentage = math.Round(spent.Float64()/limit.Float64()*10000) / 100
+		}
+
+		result.Data[i] = model.BudgetData{
+			ID:         data.ID,
+			CategoryId: data.CategoryId,
+			Category:   data.Category,
+			Limit:      limit,
+			Spent:      spent,
+			Remaining:  limit.Sub(spent),
+			Percentage: percentage,
+		}
+
+		// a budget under a budgeted parent is already part of the parent's
+		// limit and spending, the totals only count the outermost budgets
+		if hasBudgetedAncestor(data.CategoryId, parents, budgeted) {
+			continue
+		}
+
+		result.TotalLimit = result.TotalLimit.Add(limit)
+		result.TotalSpent = result.TotalSpent.Add(spent)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	data := map[string]any{
+		"value": encValue,
+	}
+
+	err = u.repo.Update(user.ID, req.ID, data, tx)
+	if err != nil {
+		u.log.Errorf("failed update budget: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) Delete(user *userModel.User, id uint) (resp common.Response) {
+	db := config.GetDatabase()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	err = u.repo.Delete(user.ID, id, tx)
+	if err != nil {
+		u.log.Errorf("failed delete budget: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func hasBudgetedAncestor(categoryId uint, parents map[uint]uint, budgeted map[uint]struct{}) bool {
+	for _, ancestor := range libs.Ancestors(categoryId, parents) {
+		if _, ok := budgeted[ancestor]; ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,231 @@
+package aggregate
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/aggregate/model"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	"github.com/jmoiron/sqlx"
+)
+
+// Aggregator keeps the encrypted income and expense totals of every user per
+// period, category and currency, so totals no longer need every transaction
+// to be read and decrypted.
+//
+// The aggregates of a user are usable only while a state row exists with the
+// current period start day. Writers take the state row lock before changing
+// an aggregate and Rebuild holds the same lock while it recalculates. A writer
+// that finds no state leaves the aggregates alone, its locking read still
+// blocks a concurrent Rebuild until the writer commits.
+type Aggregator interface {
+	Apply(userId uint, entries []model.Entry, tx *sqlx.Tx) error
+	Invalidate(userId uint, tx *sqlx.Tx) error
+	IsFresh(userId uint, dayOfMonth uint8, db *sqlx.DB) (bool, error)
+	Rebuild(userId uint, dayOfMonth uint8, load func() ([]model.Entry, error), db *sqlx.DB) error
+	List(userId uint, startDate, endDate string, db *sqlx.DB) (result []model.AggregateData, err error)
+}
+
+type aggregator struct {
+	repo Repository
+}
+
+func NewAggregator(repo Repository) Aggregator {
+	return &aggregator{
+		repo,
+	}
+}
+
+// Apply adds the entries to the aggregates inside the transaction that
+// changes the transactions themselves
+func (a *aggregator) Apply(userId uint, entries []model.Entry, tx *sqlx.Tx) error {
+	state, err := a.repo.LockState(userId, tx)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("repo.LockState: %w", err)
+	}
+
+	keyStr := fmt.Sprintf("%d", userId)
+	for _, entry := range groupEntries(userId, state.DayOfMonth, entries) {
+		value := entry.value
+
+		current, err := a.repo.Lock(&entry.Aggregate, tx)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("repo.Lock: %w", err)
+		} else if err == nil {
+			currentValue, err := decryptValue(keyStr, current.Value)
+			if err != nil {
+				return err
+			}
+
+			value = value.Add(currentValue)
+		}
+
+		entry.Value, err = libs.Encrypt(keyStr, value.String())
+		if err != nil {
+			return fmt.Errorf("error encrypting value: %w", err)
+		}
+
+		err = a.repo.Upsert([]model.Aggregate{entry.Aggregate}, tx)
+		if err != nil {
+			return fmt.Errorf("repo.Upsert: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Invalidate drops the state of a user, for changes that move amounts in a
+// way Apply cannot follow such as reassigning whole categories. The
+// aggregates are rebuilt the next time they are read.
+func (a *aggregator) Invalidate(userId uint, tx *sqlx.Tx) error {
+	return a.repo.DeleteState(userId, tx)
+}
+
+func (a *aggregator) IsFresh(userId uint, dayOfMonth uint8, db *sqlx.DB) (bool, error) {
+	state, err := a.repo.GetState(userId, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("repo.GetState: %w", err)
+	}
+
+	return state.DayOfMonth == dayOfMonth, nil
+}
+
+// Rebuild recalculates every aggregate of a user from the entries returned by
+// load. load is called once the state lock is held so no change is missed.
+func (a *aggregator) Rebuild(userId uint, dayOfMonth uint8, load func() ([]model.Entry, error), db *sqlx.DB) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = a.repo.SaveState(&model.State{UserId: userId, DayOfMonth: dayOfMonth}, tx)
+	if err != nil {
+		return fmt.Errorf("repo.SaveState: %w", err)
+	}
+
+	entries, err := load()
+	if err != nil {
+		return err
+	}
+
+	err = a.repo.DeleteByUser(userId, tx)
+	if err != nil {
+		return fmt.Errorf("repo.DeleteByUser: %w", err)
+	}
+
+	keyStr := fmt.Sprintf("%d", userId)
+	groups := groupEntries(userId, dayOfMonth, entries)
+	data := make([]model.Aggregate, len(groups))
+	for i, group := range groups {
+		group.Value, err = libs.Encrypt(keyStr, group.value.String())
+		if err != nil {
+			return fmt.Errorf("error encrypting value: %w", err)
+		}
+
+		data[i] = group.Aggregate
+	}
+
+	err = a.repo.Upsert(data, tx)
+	if err != nil {
+		return fmt.Errorf("repo.Upsert: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the decrypted aggregates of the periods starting between
+// startDate and endDate, or of every period when the dates are empty
+func (a *aggregator) List(userId uint, startDate, endDate string, db *sqlx.DB) (result []model.AggregateData, err error) {
+	listData, err := a.repo.List(userId, startDate, endDate, db)
+	if err != nil {
+		return nil, fmt.Errorf("repo.List: %w", err)
+	}
+
+	keyStr := fmt.Sprintf("%d", userId)
+	result = make([]model.AggregateData, len(listData))
+	for i, data := range listData {
+		value, err := decryptValue(keyStr, data.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		result[i] = model.AggregateData{
+			Type:        data.Type,
+			PeriodStart: data.PeriodStart,
+			CategoryId:  data.CategoryId,
+			Currency:    data.Currency,
+			Value:       value,
+		}
+	}
+
+	return
+}
+
+type group struct {
+	model.Aggregate
+	value libs.Money
+}
+
+// groupEntries sums the entries per aggregate row, keeping the order in which
+// each row first appears so rows are always locked in the same order
+func groupEntries(userId uint, dayOfMonth uint8, entries []model.Entry) []group {
+	type key struct {
+		entryType   string
+		periodStart time.Time
+		categoryId  uint
+		currency    string
+	}
+
+	index := make(map[key]int)
+	result := make([]group, 0)
+	for _, entry := range entries {
+		periodStart := period.GetRangeOf(dayOfMonth, entry.Date).StartDate
+		k := key{entry.Type, periodStart, entry.CategoryId, entry.Currency}
+
+		i, ok := index[k]
+		if !ok {
+			i = len(result)
+			index[k] = i
+			result = append(result, group{
+				Aggregate: model.Aggregate{
+					UserId:      userId,
+					Type:        entry.Type,
+					PeriodStart: periodStart.Format(constant.DateFormat),
+					CategoryId:  entry.CategoryId,
+					Currency:    entry.Currency,
+				},
+			})
+		}
+
+		result[i].value = result[i].value.Add(entry.Value)
+	}
+
+	return result
+}
+
+func decryptValue(keyStr, cipher string) (libs.Money, error) {
+	decValue, err := libs.Decrypt(keyStr, cipher)
+	if err != nil {
+		return libs.Money{}, fmt.Errorf("error decrypting value: %w", err)
+	}
+
+	value, err := libs.ParseMoney(decValue)
+	if err != nil {
+		return libs.Money{}, fmt.Errorf("error parsing string: %w", err)
+	}
+
+	return value, nil
+}
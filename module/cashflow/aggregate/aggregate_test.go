@@ -0,0 +1,301 @@
+package aggregate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/aggregate/model"
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/jmoiron/sqlx"
+)
+
+const testUserId = 7
+
+// useLegacyKeys loads a config that encrypts with the legacy key format, which
+// needs no data key from the database
+func useLegacyKeys(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	content := `{"secret": {"encryptionKey": "test-key", "dataKeyVersion": 0}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(dir)
+	config.NewViper()
+}
+
+// fakeRepository keeps the state and aggregates in memory and records the
+// order of the calls that change them
+type fakeRepository struct {
+	state *model.State
+	rows  map[string]model.Aggregate
+	calls []string
+}
+
+func newFakeRepository(state *model.State) *fakeRepository {
+	return &fakeRepository{state: state, rows: make(map[string]model.Aggregate)}
+}
+
+func rowKey(entryType, periodStart string, categoryId uint, currency string) string {
+	return fmt.Sprintf("%s|%s|%d|%s", entryType, periodStart, categoryId, currency)
+}
+
+func (r *fakeRepository) GetState(userId uint, db *sqlx.DB) (model.State, error) {
+	if r.state == nil {
+		return model.State{}, fmt.Errorf("failed to execute query: %w", sql.ErrNoRows)
+	}
+
+	return *r.state, nil
+}
+
+func (r *fakeRepository) LockState(userId uint, tx *sqlx.Tx) (model.State, error) {
+	return r.GetState(userId, nil)
+}
+
+func (r *fakeRepository) SaveState(data *model.State, tx *sqlx.Tx) error {
+	r.calls = append(r.calls, "SaveState")
+	state := *data
+	r.state = &state
+	return nil
+}
+
+func (r *fakeRepository) DeleteState(userId uint, tx *sqlx.Tx) error {
+	r.calls = append(r.calls, "DeleteState")
+	r.state = nil
+	return nil
+}
+
+func (r *fakeRepository) Lock(data *model.Aggregate, tx *sqlx.Tx) (model.GetAggregate, error) {
+	row, ok := r.rows[rowKey(data.Type, data.PeriodStart, data.CategoryId, data.Currency)]
+	if !ok {
+		return model.GetAggregate{}, fmt.Errorf("failed to execute query: %w", sql.ErrNoRows)
+	}
+
+	return model.GetAggregate{Type: row.Type, CategoryId: row.CategoryId, Currency: row.Currency, Value: row.Value}, nil
+}
+
+func (r *fakeRepository) Upsert(data []model.Aggregate, tx *sqlx.Tx) error {
+	r.calls = append(r.calls, "Upsert")
+	for _, row := range data {
+		r.rows[rowKey(row.Type, row.PeriodStart, row.CategoryId, row.Currency)] = row
+	}
+	return nil
+}
+
+func (r *fakeRepository) DeleteByUser(userId uint, tx *sqlx.Tx) error {
+	r.calls = append(r.calls, "DeleteByUser")
+	clear(r.rows)
+	return nil
+}
+
+func (r *fakeRepository) List(userId uint, startDate, endDate string, db *sqlx.DB) ([]model.GetAggregate, error) {
+	return nil, errors.New("not implemented")
+}
+
+// values decrypts every stored aggregate
+func (r *fakeRepository) values(t *testing.T) map[string]string {
+	t.Helper()
+
+	result := make(map[string]string, len(r.rows))
+	for k, row := range r.rows {
+		value, err := decryptValue(fmt.Sprintf("%d", testUserId), row.Value)
+		if err != nil {
+			t.Fatalf("stored value of %s: %v", k, err)
+		}
+
+		result[k] = value.String()
+	}
+
+	return result
+}
+
+func (r *fakeRepository) put(t *testing.T, entryType, periodStart string, categoryId uint, currency, value string) {
+	t.Helper()
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", testUserId), value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.rows[rowKey(entryType, periodStart, categoryId, currency)] = model.Aggregate{
+		UserId:      testUserId,
+		Type:        entryType,
+		PeriodStart: periodStart,
+		CategoryId:  categoryId,
+		Currency:    currency,
+		Value:       encValue,
+	}
+}
+
+// noopDriver opens connections that only begin, commit and roll back, which is
+// all Rebuild asks of the database itself
+type noopDriver struct{}
+
+func (noopDriver) Open(string) (driver.Conn, error) { return noopConn{}, nil }
+
+func (noopDriver) Connect(context.Context) (driver.Conn, error) { return noopConn{}, nil }
+
+func (d noopDriver) Driver() driver.Driver { return d }
+
+type noopConn struct{}
+
+func (noopConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+
+func (noopConn) Close() error { return nil }
+
+func (noopConn) Begin() (driver.Tx, error) { return noopConn{}, nil }
+
+func (noopConn) Commit() error { return nil }
+
+func (noopConn) Rollback() error { return nil }
+
+func entry(entryType string, categoryId uint, date, currency, value string) model.Entry {
+	parsed, err := time.ParseInLocation(constant.DateFormat, date, time.Local)
+	if err != nil {
+		panic(err)
+	}
+
+	return model.Entry{
+		Type:       entryType,
+		CategoryId: categoryId,
+		Date:       parsed,
+		Currency:   currency,
+		Value:      libs.MustParseMoney(value),
+	}
+}
+
+func TestApply(t *testing.T) {
+	useLegacyKeys(t)
+
+	repo := newFakeRepository(&model.State{UserId: testUserId, DayOfMonth: 25})
+	repo.put(t, cashflowModel.TypeExpense, "2026-01-25", 1, "IDR", "100")
+
+	err := NewAggregator(repo).Apply(testUserId, []model.Entry{
+		entry(cashflowModel.TypeExpense, 1, "2026-02-01", "IDR", "50"),
+		entry(cashflowModel.TypeExpense, 1, "2026-02-10", "IDR", "-30"),
+		entry(cashflowModel.TypeExpense, 1, "2026-02-25", "IDR", "10"),
+		entry(cashflowModel.TypeExpense, 1, "2026-02-01", "USD", "5.5"),
+		entry(cashflowModel.TypeIncome, 1, "2026-02-01", "IDR", "8"),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	want := map[string]string{
+		rowKey(cashflowModel.TypeExpense, "2026-01-25", 1, "IDR"): "120",
+		rowKey(cashflowModel.TypeExpense, "2026-02-25", 1, "IDR"): "10",
+		rowKey(cashflowModel.TypeExpense, "2026-01-25", 1, "USD"): "5.5",
+		rowKey(cashflowModel.TypeIncome, "2026-01-25", 1, "IDR"):  "8",
+	}
+
+	if got := repo.values(t); !maps.Equal(got, want) {
+		t.Errorf("aggregates = %v, want %v", got, want)
+	}
+}
+
+func TestApplyWithoutState(t *testing.T) {
+	useLegacyKeys(t)
+
+	repo := newFakeRepository(&model.State{UserId: testUserId, DayOfMonth: 1})
+	repo.put(t, cashflowModel.TypeExpense, "2026-02-01", 1, "IDR", "100")
+
+	aggregator := NewAggregator(repo)
+	if err := aggregator.Invalidate(testUserId, nil); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+
+	err := aggregator.Apply(testUserId, []model.Entry{
+		entry(cashflowModel.TypeExpense, 1, "2026-02-10", "IDR", "50"),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	// stale aggregates are left alone until the next rebuild
+	if got := repo.values(t)[rowKey(cashflowModel.TypeExpense, "2026-02-01", 1, "IDR")]; got != "100" {
+		t.Errorf("aggregate = %q, want 100", got)
+	}
+
+	fresh, err := aggregator.IsFresh(testUserId, 1, nil)
+	if err != nil || fresh {
+		t.Errorf("IsFresh() = %v, %v, want false", fresh, err)
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	useLegacyKeys(t)
+
+	repo := newFakeRepository(&model.State{UserId: testUserId, DayOfMonth: 1})
+	repo.put(t, cashflowModel.TypeExpense, "2026-02-01", 1, "IDR", "100")
+
+	db := sqlx.NewDb(sql.OpenDB(noopDriver{}), "mysql")
+	defer db.Close()
+
+	aggregator := NewAggregator(repo)
+	err := aggregator.Rebuild(testUserId, 25, func() ([]model.Entry, error) {
+		repo.calls = append(repo.calls, "load")
+		return []model.Entry{
+			entry(cashflowModel.TypeExpense, 1, "2026-02-01", "IDR", "40"),
+			entry(cashflowModel.TypeExpense, 1, "2026-02-24", "IDR", "2"),
+			entry(cashflowModel.TypeExpense, 1, "2026-02-25", "IDR", "7"),
+		}, nil
+	}, db)
+	if err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+
+	// the entries are loaded once the state lock is held
+	if want := []string{"SaveState", "load", "DeleteByUser", "Upsert"}; !slices.Equal(repo.calls, want) {
+		t.Errorf("calls = %v, want %v", repo.calls, want)
+	}
+
+	want := map[string]string{
+		rowKey(cashflowModel.TypeExpense, "2026-01-25", 1, "IDR"): "42",
+		rowKey(cashflowModel.TypeExpense, "2026-02-25", 1, "IDR"): "7",
+	}
+
+	if got := repo.values(t); !maps.Equal(got, want) {
+		t.Errorf("aggregates = %v, want %v", got, want)
+	}
+
+	for dayOfMonth, want := range map[uint8]bool{25: true, 1: false} {
+		fresh, err := aggregator.IsFresh(testUserId, dayOfMonth, nil)
+		if err != nil || fresh != want {
+			t.Errorf("IsFresh(%d) = %v, %v, want %v", dayOfMonth, fresh, err, want)
+		}
+	}
+}
+
+func TestRebuildLoadError(t *testing.T) {
+	useLegacyKeys(t)
+
+	repo := newFakeRepository(nil)
+	db := sqlx.NewDb(sql.OpenDB(noopDriver{}), "mysql")
+	defer db.Close()
+
+	loadErr := errors.New("load failed")
+	err := NewAggregator(repo).Rebuild(testUserId, 1, func() ([]model.Entry, error) {
+		return nil, loadErr
+	}, db)
+	if !errors.Is(err, loadErr) {
+		t.Errorf("Rebuild() error = %v, want %v", err, loadErr)
+	}
+
+	if slices.Contains(repo.calls, "DeleteByUser") {
+		t.Errorf("aggregates were deleted after a failed load: %v", repo.calls)
+	}
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"github.com/fazriegi/money_management-be/libs"
+)
+
+// State records the period start day the aggregates of a user were built
+// with. A user without a state has no usable aggregates.
+type State struct {
+	UserId     uint  `db:"user_id"`
+	DayOfMonth uint8 `db:"day_of_month"`
+}
+
+type Aggregate struct {
+	ID          uint   `db:"id"`
+	UserId      uint   `db:"user_id"`
+	Type        string `db:"type"`
+	PeriodStart string `db:"period_start"`
+	CategoryId  uint   `db:"category_id"`
+	Currency    string `db:"currency"`
+	Value       string `db:"value"`
+}
+
+type GetAggregate struct {
+	ID          uint      `db:"id"`
+	Type        string    `db:"type"`
+	PeriodStart time.Time `db:"period_start"`
+	CategoryId  uint      `db:"category_id"`
+	Currency    string    `db:"currency"`
+	Value       string    `db:"value"`
+}
+
+type AggregateData struct {
+	Type        string
+	PeriodStart time.Time
+	CategoryId  uint
+	Currency    string
+	Value       libs.Money
+}
+
+// Entry is a change to the aggregates, a negative value takes an amount out
+type Entry struct {
+	Type       string
+	CategoryId uint
+	Date       time.Time
+	Currency   string
+	Value      libs.Money
+}
@@ -0,0 +1,229 @@
+package aggregate
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/aggregate/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetState(userId uint, db *sqlx.DB) (result model.State, err error)
+	LockState(userId uint, tx *sqlx.Tx) (result model.State, err error)
+	SaveState(data *model.State, tx *sqlx.Tx) error
+	DeleteState(userId uint, tx *sqlx.Tx) error
+	Lock(data *model.Aggregate, tx *sqlx.Tx) (result model.GetAggregate, err error)
+	Upsert(data []model.Aggregate, tx *sqlx.Tx) error
+	DeleteByUser(userId uint, tx *sqlx.Tx) error
+	List(userId uint, startDate, endDate string, db *sqlx.DB) (result []model.GetAggregate, err error)
+}
+
+type repository struct{}
+
+func NewRepository() Repository {
+	return &repository{}
+}
+
+func (r *repository) GetState(userId uint, db *sqlx.DB) (result model.State, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("cashflow_aggregate_state").
+		Select(
+			goqu.I("user_id"),
+			goqu.I("day_of_month"),
+		).
+		Where(goqu.I("user_id").Eq(userId))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+// LockState reads the state and holds it until the transaction ends, so
+// changes to the aggregates of a user never interleave
+func (r *repository) LockState(userId uint, tx *sqlx.Tx) (result model.State, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("cashflow_aggregate_state").
+		Select(
+			goqu.I("user_id"),
+			goqu.I("day_of_month"),
+		).
+		Where(goqu.I("user_id").Eq(userId)).
+		ForUpdate(exp.Wait)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = tx.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) SaveState(data *model.State, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("cashflow_aggregate_state").
+		Rows(*data).
+		OnConflict(goqu.DoUpdate("user_id", goqu.Record{"day_of_month": goqu.L("VALUES(day_of_month)")}))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteState(userId uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Delete("cashflow_aggregate_state").
+		Where(goqu.I("user_id").Eq(userId))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) Lock(data *model.Aggregate, tx *sqlx.Tx) (result model.GetAggregate, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("cashflow_aggregate").
+		Select(
+			goqu.I("id"),
+			goqu.I("type"),
+			goqu.I("period_start"),
+			goqu.I("category_id"),
+			goqu.I("currency"),
+			goqu.I("value"),
+		).
+		Where(
+			goqu.I("user_id").Eq(data.UserId),
+			goqu.I("type").Eq(data.Type),
+			goqu.I("period_start").Eq(data.PeriodStart),
+			goqu.I("category_id").Eq(data.CategoryId),
+			goqu.I("currency").Eq(data.Currency),
+		).
+		ForUpdate(exp.Wait)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = tx.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) Upsert(data []model.Aggregate, tx *sqlx.Tx) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("cashflow_aggregate").
+		Cols("user_id", "type", "period_start", "category_id", "currency", "value")
+	for _, v := range data {
+		dataset = dataset.Vals(goqu.Vals{v.UserId, v.Type, v.PeriodStart, v.CategoryId, v.Currency, v.Value})
+	}
+	dataset = dataset.OnConflict(goqu.DoUpdate("value", goqu.Record{"value": goqu.L("VALUES(value)")}))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteByUser(userId uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Delete("cashflow_aggregate").
+		Where(goqu.I("user_id").Eq(userId))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) List(userId uint, startDate, endDate string, db *sqlx.DB) (result []model.GetAggregate, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("cashflow_aggregate").
+		Select(
+			goqu.I("id"),
+			goqu.I("type"),
+			goqu.I("period_start"),
+			goqu.I("category_id"),
+			goqu.I("currency"),
+			goqu.I("value"),
+		).
+		Where(goqu.I("user_id").Eq(userId)).
+		Order(goqu.I("period_start").Asc())
+
+	if startDate != "" && endDate != "" {
+		dataset = dataset.Where(goqu.I("period_start").Between(exp.NewRangeVal(startDate, endDate)))
+	}
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.GetAggregate, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
@@ -1,9 +1,11 @@
 package cashflow
 
 import (
+	"bufio"
 	"net/http"
 
 	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
 	"github.com/fazriegi/money_management-be/module/cashflow/model"
 	"github.com/fazriegi/money_management-be/module/common"
 	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
@@ -13,6 +15,9 @@ import (
 
 type Controller interface {
 	List(ctx *fiber.Ctx) error
+	Export(ctx *fiber.Ctx) error
+	Duplicates(ctx *fiber.Ctx) error
+	ResolveDuplicates(ctx *fiber.Ctx) error
 }
 
 type controller struct {
@@ -40,7 +45,112 @@ func (c *controller) List(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseQueryParamErr, nil))
 	}
 
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
 	response = c.usecase.List(&user, &reqBody)
 
 	return ctx.Status(response.Status.Code).JSON(response)
 }
+
+func (c *controller) Export(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.ListRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	if err := ctx.QueryParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing query param: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseQueryParamErr, nil))
+	}
+
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
+	response, write := c.usecase.Export(&user, &reqBody)
+	if !response.IsSuccess {
+		return ctx.Status(response.Status.Code).JSON(response)
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/csv")
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="cashflow.csv"`)
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := write(w); err != nil {
+			c.log.Errorf("error writing cashflow csv: %s", err.Error())
+		}
+	})
+
+	return nil
+}
+
+func (c *controller) Duplicates(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.DuplicateRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	if err := ctx.QueryParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing query param: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseQueryParamErr, nil))
+	}
+
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
+	response = c.usecase.Duplicates(&user, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
+
+func (c *controller) ResolveDuplicates(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.ResolveDuplicateRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing request body: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseReqBodyErr, nil))
+	}
+
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
+	response = c.usecase.ResolveDuplicates(&user, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
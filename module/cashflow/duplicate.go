@@ -0,0 +1,190 @@
+package cashflow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/model"
+)
+
+const (
+	defaultDuplicateDays = 3
+
+	// minNotesSimilarity is the share of character pairs two notes must have
+	// in common to be considered the same entry
+	minNotesSimilarity = 0.6
+)
+
+type candidate struct {
+	model.GetTransaction
+	date  time.Time
+	value libs.Money
+}
+
+// findDuplicates groups the candidates of the same type, category, currency
+// and value whose dates are at most days apart and whose notes are similar.
+// Only groups with a transaction dated within the range are returned.
+func findDuplicates(candidates []candidate, days uint, startDate, endDate string) []model.DuplicateGroup {
+	buckets := make(map[string][]int)
+	keys := make([]string, 0)
+	for i, c := range candidates {
+		key := fmt.Sprintf("%s/%d/%s/%s", c.Type, c.CategoryId, c.Currency, c.value.String())
+		if _, ok := buckets[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		buckets[key] = append(buckets[key], i)
+	}
+
+	maxGap := time.Duration(days) * 24 * time.Hour
+
+	groups := make([]model.DuplicateGroup, 0)
+	for _, key := range keys {
+		indexes := buckets[key]
+		slices.SortStableFunc(indexes, func(a, b int) int {
+			return candidates[a].date.Compare(candidates[b].date)
+		})
+
+		clusters := make([][]int, 0)
+		for _, index := range indexes {
+			joined := false
+			for c, members := range clusters {
+				if belongsTo(candidates, members, index, maxGap) {
+					clusters[c] = append(members, index)
+					joined = true
+					break
+				}
+			}
+
+			if !joined {
+				clusters = append(clusters, []int{index})
+			}
+		}
+
+		for _, members := range clusters {
+			if len(members) < 2 || !withinRange(candidates, members, startDate, endDate) {
+				continue
+			}
+
+			first := candidates[members[0]]
+			group := model.DuplicateGroup{
+				Type:         first.Type,
+				CategoryId:   first.CategoryId,
+				Category:     first.Category,
+				Value:        first.value,
+				Currency:     first.Currency,
+				Transactions: make([]model.DuplicateTransaction, len(members)),
+			}
+
+			for i, member := range members {
+				c := candidates[member]
+				group.Transactions[i] = model.DuplicateTransaction{
+					ID:        c.ID,
+					Date:      c.Date,
+					Notes:     c.Notes,
+					AccountId: c.AccountId,
+					Account:   c.Account,
+				}
+			}
+
+			groups = append(groups, group)
+		}
+	}
+
+	slices.SortStableFunc(groups, func(a, b model.DuplicateGroup) int {
+		return libs.ParseDate(a.Transactions[0].Date).Compare(libs.ParseDate(b.Transactions[0].Date))
+	})
+
+	return groups
+}
+
+func withinRange(candidates []candidate, members []int, startDate, endDate string) bool {
+	for _, member := range members {
+		date := candidates[member].date.Format(constant.DateFormat)
+		if date >= startDate && date <= endDate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// belongsTo tells whether the candidate is close in date to and has notes
+// similar to every member of the cluster, so duplicates do not chain through
+// a transaction without notes
+func belongsTo(candidates []candidate, members []int, index int, maxGap time.Duration) bool {
+	for _, member := range members {
+		gap := candidates[index].date.Sub(candidates[member].date)
+		if gap < 0 {
+			gap = -gap
+		}
+
+		if gap > maxGap || !similarNotes(candidates[index].Notes, candidates[member].Notes) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// similarNotes treats empty notes as matching anything, since a transaction
+// typed in by hand often has none while its imported copy does
+func similarNotes(a, b string) bool {
+	a, b = normalizeNotes(a), normalizeNotes(b)
+	if a == "" || b == "" || strings.Contains(a, b) || strings.Contains(b, a) {
+		return true
+	}
+
+	return diceCoefficient(a, b) >= minNotesSimilarity
+}
+
+func normalizeNotes(notes string) string {
+	words := strings.FieldsFunc(strings.ToLower(notes), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(words, " ")
+}
+
+// diceCoefficient compares the character pairs of both strings, 1 means they
+// have the same pairs and 0 that they share none
+func diceCoefficient(a, b string) float64 {
+	pairsA, pairsB := bigrams(a), bigrams(b)
+	if len(pairsA) == 0 || len(pairsB) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(pairsA))
+	for _, pair := range pairsA {
+		counts[pair]++
+	}
+
+	var shared int
+	for _, pair := range pairsB {
+		if counts[pair] > 0 {
+			counts[pair]--
+			shared++
+		}
+	}
+
+	return float64(2*shared) / float64(len(pairsA)+len(pairsB))
+}
+
+func bigrams(value string) []string {
+	runes := []rune(value)
+	if len(runes) < 2 {
+		return nil
+	}
+
+	result := make([]string, 0, len(runes)-1)
+	for i := 0; i < len(runes)-1; i++ {
+		result = append(result, string(runes[i:i+2]))
+	}
+
+	return result
+}
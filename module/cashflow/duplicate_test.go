@@ -0,0 +1,191 @@
+package cashflow
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/model"
+)
+
+func newCandidate(id uint, date, value, notes string) candidate {
+	return candidate{
+		GetTransaction: model.GetTransaction{
+			ID:         id,
+			Type:       model.TypeExpense,
+			CategoryId: 1,
+			Date:       date,
+			Currency:   "IDR",
+			Notes:      notes,
+		},
+		date:  libs.ParseDate(date),
+		value: libs.MustParseMoney(value),
+	}
+}
+
+func groupIds(groups []model.DuplicateGroup) [][]uint {
+	result := make([][]uint, len(groups))
+	for i, group := range groups {
+		result[i] = make([]uint, len(group.Transactions))
+		for j, transaction := range group.Transactions {
+			result[i][j] = transaction.ID
+		}
+	}
+
+	return result
+}
+
+func TestFindDuplicates(t *testing.T) {
+	otherCategory := newCandidate(2, "2026-01-05", "50", "Coffee")
+	otherCategory.CategoryId = 2
+
+	otherCurrency := newCandidate(2, "2026-01-05", "50", "Coffee")
+	otherCurrency.Currency = "USD"
+
+	income := newCandidate(2, "2026-01-05", "50", "Coffee")
+	income.Type = model.TypeIncome
+
+	tests := []struct {
+		name       string
+		candidates []candidate
+		want       [][]uint
+	}{
+		{
+			name: "same entry on the same day",
+			candidates: []candidate{
+				newCandidate(1, "2026-01-05", "50", "Coffee"),
+				newCandidate(2, "2026-01-05", "50.00", "Coffee"),
+			},
+			want: [][]uint{{1, 2}},
+		},
+		{
+			name: "dates exactly the window apart",
+			candidates: []candidate{
+				newCandidate(1, "2026-01-05", "50", "Coffee"),
+				newCandidate(2, "2026-01-08", "50", "Coffee"),
+			},
+			want: [][]uint{{1, 2}},
+		},
+		{
+			name: "dates beyond the window",
+			candidates: []candidate{
+				newCandidate(1, "2026-01-05", "50", "Coffee"),
+				newCandidate(2, "2026-01-09", "50", "Coffee"),
+			},
+			want: [][]uint{},
+		},
+		{
+			name: "different value",
+			candidates: []candidate{
+				newCandidate(1, "2026-01-05", "50", "Coffee"),
+				newCandidate(2, "2026-01-05", "50.01", "Coffee"),
+			},
+			want: [][]uint{},
+		},
+		{
+			name:       "different category",
+			candidates: []candidate{newCandidate(1, "2026-01-05", "50", "Coffee"), otherCategory},
+			want:       [][]uint{},
+		},
+		{
+			name:       "different currency",
+			candidates: []candidate{newCandidate(1, "2026-01-05", "50", "Coffee"), otherCurrency},
+			want:       [][]uint{},
+		},
+		{
+			name:       "different type",
+			candidates: []candidate{newCandidate(1, "2026-01-05", "50", "Coffee"), income},
+			want:       [][]uint{},
+		},
+		{
+			name: "a cluster does not chain past the window",
+			candidates: []candidate{
+				newCandidate(3, "2026-01-07", "50", "Coffee"),
+				newCandidate(1, "2026-01-03", "50", "Coffee"),
+				newCandidate(2, "2026-01-05", "50", "Coffee"),
+			},
+			want: [][]uint{{1, 2}},
+		},
+		{
+			name: "similar notes in another format",
+			candidates: []candidate{
+				newCandidate(1, "2026-01-05", "50", "Coffee Shop"),
+				newCandidate(2, "2026-01-06", "50", "COFFEE-SHOP #1234"),
+			},
+			want: [][]uint{{1, 2}},
+		},
+		{
+			name: "dissimilar notes",
+			candidates: []candidate{
+				newCandidate(1, "2026-01-05", "50", "Coffee Shop"),
+				newCandidate(2, "2026-01-05", "50", "Parking"),
+			},
+			want: [][]uint{},
+		},
+		{
+			name: "empty notes do not chain dissimilar notes",
+			candidates: []candidate{
+				newCandidate(1, "2026-01-05", "50", "Coffee Shop"),
+				newCandidate(2, "2026-01-05", "50", ""),
+				newCandidate(3, "2026-01-05", "50", "Parking"),
+			},
+			want: [][]uint{{1, 2}},
+		},
+		{
+			name: "groups entirely outside the range are dropped",
+			candidates: []candidate{
+				newCandidate(1, "2025-12-20", "50", "Coffee"),
+				newCandidate(2, "2025-12-21", "50", "Coffee"),
+			},
+			want: [][]uint{},
+		},
+		{
+			name: "one member within the range keeps the group",
+			candidates: []candidate{
+				newCandidate(1, "2025-12-30", "50", "Coffee"),
+				newCandidate(2, "2026-01-01", "50", "Coffee"),
+			},
+			want: [][]uint{{1, 2}},
+		},
+		{
+			name: "groups are ordered by their first date",
+			candidates: []candidate{
+				newCandidate(1, "2026-01-20", "75", "Lunch"),
+				newCandidate(2, "2026-01-05", "50", "Coffee"),
+				newCandidate(3, "2026-01-21", "75", "Lunch"),
+				newCandidate(4, "2026-01-05", "50", "Coffee"),
+			},
+			want: [][]uint{{2, 4}, {1, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := groupIds(findDuplicates(tt.candidates, defaultDuplicateDays, "2026-01-01", "2026-01-31"))
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("findDuplicates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilarNotes(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Coffee Shop", "coffee shop", true},
+		{"Coffee Shop", "POS COFFEE SHOP 0412", true},
+		{"Starbucks Coffee", "Starbuck Coffee", true},
+		{"Netflix", "", true},
+		{"Netflix", "Spotify", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := similarNotes(tt.a, tt.b); got != tt.want {
+				t.Errorf("similarNotes(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package expense
 
 import (
+	"bufio"
+	"encoding/json"
 	"net/http"
 
 	"github.com/fazriegi/money_management-be/constant"
@@ -15,10 +17,15 @@ import (
 type Controller interface {
 	Add(ctx *fiber.Ctx) error
 	List(ctx *fiber.Ctx) error
+	Export(ctx *fiber.Ctx) error
 	Update(ctx *fiber.Ctx) error
 	Delete(ctx *fiber.Ctx) error
 	ListCategory(ctx *fiber.Ctx) error
 	GetById(ctx *fiber.Ctx) error
+	AddCategory(ctx *fiber.Ctx) error
+	UpdateCategory(ctx *fiber.Ctx) error
+	DeleteCategory(ctx *fiber.Ctx) error
+	MergeCategory(ctx *fiber.Ctx) error
 }
 
 type controller struct {
@@ -79,6 +86,35 @@ func (c *controller) List(ctx *fiber.Ctx) error {
 	return ctx.Status(response.Status.Code).JSON(response)
 }
 
+func (c *controller) Export(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.ListRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	if err := ctx.QueryParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing query param: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseQueryParamErr, nil))
+	}
+
+	response, write := c.usecase.Export(&user, &reqBody)
+	if !response.IsSuccess {
+		return ctx.Status(response.Status.Code).JSON(response)
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/csv")
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="expense.csv"`)
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := write(w); err != nil {
+			c.log.Errorf("error writing expense csv: %s", err.Error())
+		}
+	})
+
+	return nil
+}
+
 func (c *controller) Update(ctx *fiber.Ctx) error {
 	var (
 		response common.Response
@@ -98,6 +134,11 @@ func (c *controller) Update(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseReqBodyErr, nil))
 	}
 
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(ctx.Body(), &fields); err == nil {
+		_, reqBody.AccountIdSet = fields["account_id"]
+	}
+
 	// validate reqBody struct
 	validationErr := libs.ValidateRequest(&reqBody)
 	if len(validationErr) > 0 {
@@ -158,3 +199,124 @@ func (c *controller) GetById(ctx *fiber.Ctx) error {
 
 	return ctx.Status(response.Status.Code).JSON(response)
 }
+
+func (c *controller) AddCategory(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.CategoryRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing request body: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseReqBodyErr, nil))
+	}
+
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
+	response = c.usecase.AddCategory(&user, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
+
+func (c *controller) UpdateCategory(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.CategoryRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		c.log.Errorf("error get param: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, "invalid id", nil))
+	}
+
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing request body: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseReqBodyErr, nil))
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(ctx.Body(), &fields); err == nil {
+		_, reqBody.ParentIdSet = fields["parent_id"]
+	}
+
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
+	reqBody.ID = uint(id)
+	response = c.usecase.UpdateCategory(&user, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
+
+func (c *controller) DeleteCategory(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.DeleteCategoryRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	id, err := ctx.ParamsInt("id")
+	if err != nil {
+		c.log.Errorf("error get param: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, "invalid id", nil))
+	}
+
+	if err := ctx.QueryParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing query param: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseQueryParamErr, nil))
+	}
+
+	reqBody.ID = uint(id)
+	response = c.usecase.DeleteCategory(&user, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
+
+func (c *controller) MergeCategory(ctx *fiber.Ctx) error {
+	var (
+		response common.Response
+		reqBody  model.MergeCategoryRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing request body: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseReqBodyErr, nil))
+	}
+
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
+	response = c.usecase.MergeCategory(&user, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
@@ -8,17 +8,32 @@ import (
 	"github.com/fazriegi/money_management-be/libs"
 	"github.com/fazriegi/money_management-be/module/cashflow/expense/model"
 	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/common"
+	tagModel "github.com/fazriegi/money_management-be/module/master/tag/model"
 	"github.com/jmoiron/sqlx"
 )
 
 type Repository interface {
 	Insert(data *model.Expense, tx *sqlx.Tx) error
 	List(req *model.ListRequest, db *sqlx.DB) (result []model.GetExpense, err error)
+	Export(req *model.ListRequest, db *sqlx.DB) (*sqlx.Rows, error)
 	ListCategory(userID uint, db *sqlx.DB) (result []model.ExpenseCategory, err error)
 	Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error
 	Delete(userId, id uint, tx *sqlx.Tx) error
 	CreateListQuery(req *cashflowModel.ListFilter) *goqu.SelectDataset
 	GetById(userId, id uint, db *sqlx.DB) (result model.GetExpense, err error)
+	GetForUpdate(userId, id uint, tx *sqlx.Tx) (result model.Expense, err error)
+	ReplaceTag(expenseId uint, tagIds []uint, tx *sqlx.Tx) error
+	ListTag(expenseIds []uint, db *sqlx.DB) (result []tagModel.TransactionTag, err error)
+	ListExternalId(userId uint, externalIds []string, db *sqlx.DB) (result []string, err error)
+	GetCategoryById(userId, id uint, db *sqlx.DB) (result model.ExpenseCategory, err error)
+	InsertCategory(data *model.ExpenseCategory, tx *sqlx.Tx) error
+	UpdateCategory(userId, id uint, data map[string]any, tx *sqlx.Tx) error
+	DeleteCategory(userId uint, ids []uint, tx *sqlx.Tx) error
+	CountByCategory(userId, categoryId uint, tx *sqlx.Tx) (total uint, err error)
+	ReassignCategory(userId uint, fromIds []uint, toId uint, tx *sqlx.Tx) error
+	ReparentCategory(userId uint, fromIds []uint, toId uint, tx *sqlx.Tx) error
+	ListCategoryDescendant(userId, categoryId uint, db *sqlx.DB) (result []uint, err error)
 }
 
 type repository struct{}
@@ -36,33 +51,24 @@ func (r *repository) Insert(data *model.Expense, tx *sqlx.Tx) error {
 		return fmt.Errorf("failed to build SQL query: %w", err)
 	}
 
-	_, err = tx.Exec(sql, val...)
+	res, err := tx.Exec(sql, val...)
 	if err != nil {
 		return fmt.Errorf("failed to execute insert: %w", err)
 	}
 
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	data.ID = uint(id)
+
 	return nil
 
 }
 
 func (r *repository) List(req *model.ListRequest, db *sqlx.DB) (result []model.GetExpense, err error) {
-	if req.Sort == nil {
-		sort := "date desc"
-		req.Sort = &sort
-	}
-
-	listFilter := cashflowModel.ListFilter{
-		UserId:    req.UserId,
-		StartDate: req.StartDate,
-		EndDate:   req.EndDate,
-	}
-	dataset := r.CreateListQuery(&listFilter)
-
-	if req.Keyword != "" {
-		dataset = dataset.Where(goqu.I("uec.name").ILike("%" + req.Keyword + "%"))
-	}
-
-	dataset = libs.PaginationRequest(dataset, req.PaginationRequest)
+	dataset := libs.PaginationRequest(r.listQuery(req), req.PaginationRequest)
 
 	sql, val, err := dataset.ToSQL()
 	if err != nil {
@@ -84,6 +90,48 @@ func (r *repository) List(req *model.ListRequest, db *sqlx.DB) (result []model.G
 	return
 }
 
+// Export runs the list query without paging and leaves reading the rows to
+// the caller, so large exports are never held in memory
+func (r *repository) Export(req *model.ListRequest, db *sqlx.DB) (*sqlx.Rows, error) {
+	dataset := libs.PaginationRequest(r.listQuery(req), common.PaginationRequest{Sort: req.Sort})
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	row, err := db.Queryx(sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return row, nil
+}
+
+func (r *repository) listQuery(req *model.ListRequest) *goqu.SelectDataset {
+	if req.Sort == nil {
+		sort := "date desc"
+		req.Sort = &sort
+	}
+
+	listFilter := cashflowModel.ListFilter{
+		UserId:      req.UserId,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		CategoryIds: req.CategoryIds,
+		AccountId:   req.AccountId,
+		TagIds:      req.TagIds,
+		Currency:    req.Currency,
+	}
+	dataset := r.CreateListQuery(&listFilter)
+
+	if req.Keyword != "" {
+		dataset = dataset.Where(goqu.I("uec.name").ILike("%" + req.Keyword + "%"))
+	}
+
+	return dataset
+}
+
 func (r *repository) Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error {
 	dialect := libs.GetDialect()
 
@@ -180,6 +228,9 @@ func (r *repository) CreateListQuery(req *cashflowModel.ListFilter) *goqu.Select
 			goqu.I("uec.id").Eq(goqu.I("expense.category_id")),
 			goqu.I("uec.user_id").Eq(goqu.I("expense.user_id")),
 		)).
+		LeftJoin(goqu.T("account").As("acc"), goqu.On(
+			goqu.I("acc.id").Eq(goqu.I("expense.account_id")),
+		)).
 		Select(
 			goqu.I("expense.id"),
 			goqu.I("expense.category_id"),
@@ -188,6 +239,10 @@ func (r *repository) CreateListQuery(req *cashflowModel.ListFilter) *goqu.Select
 			goqu.I("expense.value"),
 			goqu.I("expense.user_id"),
 			goqu.V("expense").As("type"),
+			goqu.I("expense.account_id"),
+			goqu.I("acc.name").As("account"),
+			goqu.I("expense.currency"),
+			goqu.COALESCE(goqu.I("expense.notes"), "").As("notes"),
 		).
 		Where(
 			goqu.I("expense.user_id").Eq(req.UserId),
@@ -197,6 +252,27 @@ func (r *repository) CreateListQuery(req *cashflowModel.ListFilter) *goqu.Select
 		dataset = dataset.Where(goqu.I("expense.date").Between(exp.NewRangeVal(req.StartDate, req.EndDate)))
 	}
 
+	if len(req.CategoryIds) > 0 {
+		dataset = dataset.Where(goqu.I("expense.category_id").In(req.CategoryIds))
+	}
+
+	if req.AccountId != 0 {
+		dataset = dataset.Where(goqu.I("expense.account_id").Eq(req.AccountId))
+	}
+
+	if req.Currency != "" {
+		dataset = dataset.Where(goqu.I("expense.currency").Eq(req.Currency))
+	}
+
+	// any of the tags matches
+	if len(req.TagIds) > 0 {
+		dataset = dataset.Where(goqu.I("expense.id").In(
+			dialect.From("expense_tag").
+				Select(goqu.I("expense_id")).
+				Where(goqu.I("tag_id").In(req.TagIds)),
+		))
+	}
+
 	return dataset
 }
 
@@ -209,6 +285,9 @@ func (r *repository) GetById(userId, id uint, db *sqlx.DB) (result model.GetExpe
 			goqu.I("uec.id").Eq(goqu.I("expense.category_id")),
 			goqu.I("uec.user_id").Eq(goqu.I("expense.user_id")),
 		)).
+		LeftJoin(goqu.T("account").As("acc"), goqu.On(
+			goqu.I("acc.id").Eq(goqu.I("expense.account_id")),
+		)).
 		Select(
 			goqu.I("expense.id"),
 			goqu.I("expense.category_id"),
@@ -216,6 +295,9 @@ func (r *repository) GetById(userId, id uint, db *sqlx.DB) (result model.GetExpe
 			goqu.I("expense.date"),
 			goqu.I("expense.value"),
 			goqu.I("expense.notes"),
+			goqu.I("expense.account_id"),
+			goqu.I("acc.name").As("account"),
+			goqu.I("expense.currency"),
 		).
 		Where(
 			goqu.I("expense.user_id").Eq(userId),
@@ -234,3 +316,360 @@ func (r *repository) GetById(userId, id uint, db *sqlx.DB) (result model.GetExpe
 
 	return
 }
+
+// GetForUpdate reads the stored expense and locks it until the transaction ends
+func (r *repository) GetForUpdate(userId, id uint, tx *sqlx.Tx) (result model.Expense, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("expense").
+		Select(
+			goqu.I("id"),
+			goqu.I("category_id"),
+			goqu.I("date"),
+			goqu.I("value"),
+			goqu.I("user_id"),
+			goqu.COALESCE(goqu.I("notes"), "").As("notes"),
+			goqu.I("recurring_id"),
+			goqu.I("account_id"),
+			goqu.I("currency"),
+			goqu.I("external_id"),
+		).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		).
+		ForUpdate(exp.Wait)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = tx.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+// ReplaceTag sets the tags of the expense, dropping the ones not given
+func (r *repository) ReplaceTag(expenseId uint, tagIds []uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	deleteDataset := dialect.Delete("expense_tag").
+		Where(goqu.I("expense_id").Eq(expenseId))
+
+	sql, val, err := deleteDataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	if len(tagIds) == 0 {
+		return nil
+	}
+
+	rows := make([]goqu.Record, len(tagIds))
+	for i, tagId := range tagIds {
+		rows[i] = goqu.Record{"expense_id": expenseId, "tag_id": tagId}
+	}
+
+	sql, val, err = dialect.Insert("expense_tag").Rows(rows).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
+
+// ListTag returns the tags of the given expenses ordered by name
+func (r *repository) ListTag(expenseIds []uint, db *sqlx.DB) (result []tagModel.TransactionTag, err error) {
+	result = make([]tagModel.TransactionTag, 0)
+	if len(expenseIds) == 0 {
+		return
+	}
+
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From(goqu.T("expense_tag").As("it")).
+		Join(goqu.T("tag").As("t"), goqu.On(goqu.I("t.id").Eq(goqu.I("it.tag_id")))).
+		Select(
+			goqu.I("it.expense_id").As("transaction_id"),
+			goqu.I("t.id"),
+			goqu.I("t.name"),
+		).
+		Where(goqu.I("it.expense_id").In(expenseIds)).
+		Order(goqu.I("t.name").Asc())
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+// ListExternalId returns which of the given statement ids were imported before
+func (r *repository) ListExternalId(userId uint, externalIds []string, db *sqlx.DB) (result []string, err error) {
+	result = make([]string, 0)
+	if len(externalIds) == 0 {
+		return
+	}
+
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("expense").
+		Select(goqu.I("external_id")).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("external_id").In(externalIds),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) GetCategoryById(userId, id uint, db *sqlx.DB) (result model.ExpenseCategory, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("user_expense_category").
+		Select(
+			goqu.I("id"),
+			goqu.I("name"),
+			goqu.I("parent_id"),
+			goqu.I("user_id"),
+		).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&result, sql, val...)
+	if err != nil {
+		return result, err
+	}
+
+	return
+}
+
+func (r *repository) InsertCategory(data *model.ExpenseCategory, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("user_expense_category").Rows(*data)
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	res, err := tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	data.ID = uint(id)
+
+	return nil
+}
+
+func (r *repository) UpdateCategory(userId, id uint, data map[string]any, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("user_expense_category").Set(data).
+		Where(
+			goqu.I("id").Eq(id),
+			goqu.I("user_id").Eq(userId),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteCategory(userId uint, ids []uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Delete("user_expense_category").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").In(ids),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	return nil
+}
+
+// categoryRefTables point at a category of either type, telling them apart by
+// their type column, so they cannot have a foreign key to the category
+var categoryRefTables = []string{"recurring", "category_rule"}
+
+// CountByCategory counts the expenses and the rules still pointing at the category
+func (r *repository) CountByCategory(userId, categoryId uint, tx *sqlx.Tx) (total uint, err error) {
+	dialect := libs.GetDialect()
+
+	datasets := []*goqu.SelectDataset{
+		dialect.From("expense").
+			Select(goqu.COUNT("*")).
+			Where(
+				goqu.I("user_id").Eq(userId),
+				goqu.I("category_id").Eq(categoryId),
+			),
+	}
+	for _, table := range categoryRefTables {
+		datasets = append(datasets, dialect.From(table).
+			Select(goqu.COUNT("*")).
+			Where(
+				goqu.I("user_id").Eq(userId),
+				goqu.I("type").Eq(cashflowModel.TypeExpense),
+				goqu.I("category_id").Eq(categoryId),
+			))
+	}
+
+	for _, dataset := range datasets {
+		sql, val, err := dataset.ToSQL()
+		if err != nil {
+			return 0, fmt.Errorf("failed to build SQL query: %w", err)
+		}
+
+		var count uint
+		err = tx.Get(&count, sql, val...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		total += count
+	}
+
+	return
+}
+
+// ReassignCategory moves the expenses and the rules of the categories to toId
+func (r *repository) ReassignCategory(userId uint, fromIds []uint, toId uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	datasets := []*goqu.UpdateDataset{
+		dialect.Update("expense").
+			Set(goqu.Record{"category_id": toId}).
+			Where(
+				goqu.I("user_id").Eq(userId),
+				goqu.I("category_id").In(fromIds),
+			),
+	}
+	for _, table := range categoryRefTables {
+		datasets = append(datasets, dialect.Update(table).
+			Set(goqu.Record{"category_id": toId}).
+			Where(
+				goqu.I("user_id").Eq(userId),
+				goqu.I("type").Eq(cashflowModel.TypeExpense),
+				goqu.I("category_id").In(fromIds),
+			))
+	}
+
+	for _, dataset := range datasets {
+		sql, val, err := dataset.ToSQL()
+		if err != nil {
+			return fmt.Errorf("failed to build SQL query: %w", err)
+		}
+
+		_, err = tx.Exec(sql, val...)
+		if err != nil {
+			return fmt.Errorf("failed to execute update: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *repository) ReparentCategory(userId uint, fromIds []uint, toId uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("user_expense_category").
+		Set(goqu.Record{"parent_id": toId}).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("parent_id").In(fromIds),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+// ListCategoryDescendant returns categoryId followed by the ids of all of its sub-categories
+func (r *repository) ListCategoryDescendant(userId, categoryId uint, db *sqlx.DB) (result []uint, err error) {
+	categories, err := r.ListCategory(userId, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return libs.Descendants(categoryId, categoryParents(categories)), nil
+}
+
+func categoryParents(categories []model.ExpenseCategory) map[uint]uint {
+	parents := make(map[uint]uint, len(categories))
+	for _, category := range categories {
+		if category.ParentId != nil {
+			parents[category.ID] = *category.ParentId
+		}
+	}
+
+	return parents
+}
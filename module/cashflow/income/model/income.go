@@ -1,14 +1,22 @@
 package model
 
-import "github.com/fazriegi/money_management-be/module/common"
+import (
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/common"
+	tagModel "github.com/fazriegi/money_management-be/module/master/tag/model"
+)
 
 type Income struct {
-	ID         uint        `db:"id"`
-	CategoryId uint        `db:"category_id"`
-	Date       interface{} `db:"date"`
-	Value      string      `db:"value"`
-	UserId     uint        `db:"user_id"`
-	Notes      string      `db:"notes"`
+	ID          uint        `db:"id"`
+	CategoryId  uint        `db:"category_id"`
+	Date        interface{} `db:"date"`
+	Value       string      `db:"value"`
+	UserId      uint        `db:"user_id"`
+	Notes       string      `db:"notes"`
+	RecurringId *uint       `db:"recurring_id"`
+	AccountId   *uint       `db:"account_id"`
+	Currency    string      `db:"currency"`
+	ExternalId  *string     `db:"external_id"`
 }
 
 type GetIncome struct {
@@ -19,41 +27,91 @@ type GetIncome struct {
 	Value      string      `db:"value"`
 	UserId     uint        `db:"user_id"`
 	Notes      string      `db:"notes"`
+	AccountId  *uint       `db:"account_id"`
+	Account    *string     `db:"account"`
+	Currency   string      `db:"currency"`
 }
 
 type IncomeData struct {
-	ID         uint        `json:"id"`
-	CategoryId uint        `json:"category_id"`
-	Category   string      `json:"category"`
-	Date       interface{} `json:"date"`
-	Value      float64     `json:"value"`
-	Notes      string      `json:"notes"`
+	ID         uint           `json:"id"`
+	CategoryId uint           `json:"category_id"`
+	Category   string         `json:"category"`
+	Date       interface{}    `json:"date"`
+	Value      libs.Money     `json:"value"`
+	Notes      string         `json:"notes"`
+	AccountId  *uint          `json:"account_id"`
+	Account    *string        `json:"account"`
+	Currency   string         `json:"currency"`
+	Tags       []tagModel.Tag `json:"tags"`
 }
 
+// AddRequest without a category takes the category of the first matching
+// categorization rule
 type AddRequest struct {
-	CategoryId uint        `json:"category_id" validate:"required"`
-	Date       interface{} `json:"date" validate:"required"`
-	Value      float64     `json:"value" validate:"required"`
-	Notes      string      `json:"notes"`
+	CategoryId  uint        `json:"category_id"`
+	Date        interface{} `json:"date" validate:"required"`
+	Value       libs.Money  `json:"value" validate:"required"`
+	Notes       string      `json:"notes"`
+	AccountId   *uint       `json:"account_id"`
+	Currency    string      `json:"currency" validate:"omitempty,iso4217"`
+	Tags        []string    `json:"tags" validate:"omitempty,max=20,dive,required,max=50"`
+	RecurringId *uint       `json:"-"`
+	ExternalId  *string     `json:"-"`
 }
 
 type IncomeCategory struct {
-	ID   uint   `db:"id" json:"id"`
-	Name string `db:"name" json:"name"`
+	ID       uint             `db:"id" json:"id"`
+	Name     string           `db:"name" json:"name"`
+	ParentId *uint            `db:"parent_id" json:"parent_id"`
+	UserId   uint             `db:"user_id" json:"-"`
+	Children []IncomeCategory `db:"-" json:"children"`
 }
 
 type ListRequest struct {
 	common.PaginationRequest
-	Keyword   string `query:"keyword"`
-	StartDate string `query:"start_date"`
-	EndDate   string `query:"end_date"`
-	UserId    uint
+	Keyword     string `query:"keyword"`
+	CategoryId  uint   `query:"category_id"`
+	CategoryIds []uint
+	AccountId   uint   `query:"account_id"`
+	StartDate   string `query:"start_date"`
+	EndDate     string `query:"end_date"`
+	Period      string `query:"period"`
+	TagIds      []uint `query:"tag_id"`
+	Currency    string
+	UserId      uint
 }
 
+// UpdateRequest replaces the tags of the income with Tags, they are kept as they
+// are when Tags is not given
 type UpdateRequest struct {
 	ID         uint
 	CategoryId uint        `json:"category_id" validate:"required"`
 	Date       interface{} `json:"date" validate:"required"`
-	Value      float64     `json:"value"`
+	Value      libs.Money  `json:"value"`
 	Notes      string      `json:"notes"`
+	AccountId  *uint       `json:"account_id"`
+	Currency   string      `json:"currency" validate:"omitempty,iso4217"`
+	Tags       []string    `json:"tags" validate:"omitempty,max=20,dive,required,max=50"`
+	// AccountIdSet tells an account_id of null or 0, which detaches the income
+	// from its account, from a body without account_id, which keeps it
+	AccountIdSet bool `json:"-"`
+}
+
+type CategoryRequest struct {
+	ID       uint
+	Name     string `json:"name" validate:"required,max=50"`
+	ParentId *uint  `json:"parent_id"`
+	// ParentIdSet tells a parent_id of null, which moves the category to the
+	// top level, from a body without parent_id, which keeps its parent
+	ParentIdSet bool `json:"-"`
+}
+
+type DeleteCategoryRequest struct {
+	ID         uint
+	ReassignTo uint `query:"reassign_to"`
+}
+
+type MergeCategoryRequest struct {
+	SourceIds []uint `json:"source_ids" validate:"required,min=1,dive,required"`
+	TargetId  uint   `json:"target_id" validate:"required"`
 }
@@ -4,20 +4,37 @@ import (
 	"github.com/fazriegi/money_management-be/config"
 	"github.com/fazriegi/money_management-be/libs"
 	"github.com/fazriegi/money_management-be/middleware"
+	"github.com/fazriegi/money_management-be/module/cashflow/aggregate"
+	"github.com/fazriegi/money_management-be/module/cashflow/rule"
+	"github.com/fazriegi/money_management-be/module/master/account"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	"github.com/fazriegi/money_management-be/module/master/tag"
 	"github.com/gofiber/fiber/v2"
 )
 
 func NewRoute(app *fiber.App, jwt *libs.JWT) {
 	log := config.GetLogger()
 	repo := NewRepository()
-	usecase := NewUsecase(log, repo)
+	periodRepo := period.NewRepository()
+	accountRepo := account.NewRepository()
+	currencyRepo := currency.NewRepository()
+	aggregator := aggregate.NewAggregator(aggregate.NewRepository())
+	tagRepo := tag.NewRepository()
+	matcher := rule.NewMatcher(rule.NewRepository())
+	usecase := NewUsecase(log, repo, periodRepo, accountRepo, currencyRepo, tagRepo, aggregator, matcher)
 	controller := NewController(log, usecase)
 
 	route := app.Group("/income")
 	route.Post("/", middleware.Authentication(jwt), controller.Add)
 	route.Get("/", middleware.Authentication(jwt), controller.List)
+	route.Get("/export.csv", middleware.Authentication(jwt), controller.Export)
 	route.Put("/:id", middleware.Authentication(jwt), controller.Update)
 	route.Delete("/:id", middleware.Authentication(jwt), controller.Delete)
 	route.Get("/category", middleware.Authentication(jwt), controller.ListCategory)
+	route.Post("/category", middleware.Authentication(jwt), controller.AddCategory)
+	route.Put("/category/:id", middleware.Authentication(jwt), controller.UpdateCategory)
+	route.Delete("/category/:id", middleware.Authentication(jwt), controller.DeleteCategory)
+	route.Post("/category/merge", middleware.Authentication(jwt), controller.MergeCategory)
 	route.Get("/:id", middleware.Authentication(jwt), controller.GetById)
 }
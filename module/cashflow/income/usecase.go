@@ -1,43 +1,112 @@
 package income
 
 import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"strconv"
+	"slices"
 
 	"github.com/fazriegi/money_management-be/config"
 	"github.com/fazriegi/money_management-be/constant"
 	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/aggregate"
+	aggregateModel "github.com/fazriegi/money_management-be/module/cashflow/aggregate/model"
 	"github.com/fazriegi/money_management-be/module/cashflow/income/model"
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/rule"
 	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/account"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	"github.com/fazriegi/money_management-be/module/master/tag"
+	tagModel "github.com/fazriegi/money_management-be/module/master/tag/model"
 	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 )
 
 type Usecase interface {
 	Add(user *userModel.User, req *model.AddRequest) (resp common.Response)
+	AddTx(user *userModel.User, req *model.AddRequest, tx *sqlx.Tx) error
+	ResolveDuplicateTx(user *userModel.User, keepId uint, duplicateIds []uint, merge bool, tx *sqlx.Tx) error
 	ListCategory(user *userModel.User) (resp common.Response)
 	List(user *userModel.User, req *model.ListRequest) (resp common.Response)
+	Export(user *userModel.User, req *model.ListRequest) (resp common.Response, write func(w io.Writer) error)
 	Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response)
 	Delete(user *userModel.User, id uint) (resp common.Response)
 
 	GetById(user *userModel.User, id uint) (resp common.Response)
+	AddCategory(user *userModel.User, req *model.CategoryRequest) (resp common.Response)
+	UpdateCategory(user *userModel.User, req *model.CategoryRequest) (resp common.Response)
+	DeleteCategory(user *userModel.User, req *model.DeleteCategoryRequest) (resp common.Response)
+	MergeCategory(user *userModel.User, req *model.MergeCategoryRequest) (resp common.Response)
 }
 
 type usecase struct {
-	log  *logrus.Logger
-	repo Repository
+	log          *logrus.Logger
+	repo         Repository
+	periodRepo   period.Repository
+	accountRepo  account.Repository
+	currencyRepo currency.Repository
+	tagRepo      tag.Repository
+	aggregator   aggregate.Aggregator
+	matcher      rule.Matcher
 }
 
-func NewUsecase(log *logrus.Logger, repo Repository) Usecase {
+func NewUsecase(log *logrus.Logger, repo Repository, periodRepo period.Repository, accountRepo account.Repository, currencyRepo currency.Repository, tagRepo tag.Repository, aggregator aggregate.Aggregator, matcher rule.Matcher) Usecase {
 	return &usecase{
 		log,
 		repo,
+		periodRepo,
+		accountRepo,
+		currencyRepo,
+		tagRepo,
+		aggregator,
+		matcher,
 	}
 }
 
 func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.Response) {
 	db := config.GetDatabase()
+
+	if req.AccountId != nil {
+		_, err := u.accountRepo.GetById(user.ID, *req.AccountId, db)
+		if err != nil && errors.Is(err, sql.ErrNoRows) {
+			return resp.CustomResponse(http.StatusNotFound, "account not found", nil)
+		} else if err != nil {
+			u.log.Errorf("accountRepo.GetById: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	}
+
+	if req.CategoryId == 0 {
+		rules, err := u.matcher.Load(user.ID, db)
+		if err != nil {
+			u.log.Errorf("matcher.Load: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		categoryId, _, ok := rules.Match(cashflowModel.TypeIncome, req.Value, req.Notes)
+		if !ok {
+			return resp.CustomResponse(http.StatusBadRequest, "category_id is required when no rule matches", nil)
+		}
+
+		req.CategoryId = categoryId
+	}
+
+	if req.Currency == "" {
+		base, err := u.currencyRepo.GetBaseCurrency(user.ID, db)
+		if err != nil {
+			u.log.Errorf("currencyRepo.GetBaseCurrency: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		req.Currency = base
+	}
+
 	tx, err := db.Beginx()
 	if err != nil {
 		u.log.Errorf("error begin tx: %s", err.Error())
@@ -45,32 +114,58 @@ func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.
 	}
 	defer tx.Rollback()
 
-	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), fmt.Sprintf("%0.f", req.Value))
-	if err != nil {
-		u.log.Errorf("error encrypting value: %s", err.Error())
+	if err := u.AddTx(user, req, tx); err != nil {
+		u.log.Errorf("failed insert income: %s", err.Error())
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 	}
 
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", nil)
+}
+
+// AddTx stores a new income inside the given transaction, so other modules
+// can create incomes as part of their own unit of work. req.Currency must be
+// set, the caller resolves the base currency before the transaction starts
+func (u *usecase) AddTx(user *userModel.User, req *model.AddRequest, tx *sqlx.Tx) error {
+	if req.Currency == "" {
+		return errors.New("currency is required")
+	}
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
+	if err != nil {
+		return fmt.Errorf("error encrypting value: %w", err)
+	}
+
 	data := model.Income{
-		CategoryId: req.CategoryId,
-		Date:       req.Date,
-		Value:      encValue,
-		UserId:     user.ID,
-		Notes:      req.Notes,
+		CategoryId:  req.CategoryId,
+		Date:        req.Date,
+		Value:       encValue,
+		UserId:      user.ID,
+		Notes:       req.Notes,
+		RecurringId: req.RecurringId,
+		AccountId:   req.AccountId,
+		Currency:    req.Currency,
+		ExternalId:  req.ExternalId,
 	}
 
 	err = u.repo.Insert(&data, tx)
 	if err != nil {
-		u.log.Errorf("failed insert Income: %s", err.Error())
-		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		return err
 	}
 
-	if err := tx.Commit(); err != nil {
-		u.log.Errorf("failed commit tx: %s", err.Error())
-		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	if len(req.Tags) > 0 {
+		if err := u.setTags(user.ID, data.ID, req.Tags, tx); err != nil {
+			return err
+		}
 	}
 
-	return resp.CustomResponse(http.StatusCreated, "success", nil)
+	return u.aggregator.Apply(user.ID, []aggregateModel.Entry{
+		aggregateEntry(data.CategoryId, data.Date, data.Currency, req.Value),
+	}, tx)
 }
 
 func (u *usecase) ListCategory(user *userModel.User) (resp common.Response) {
@@ -82,19 +177,37 @@ func (u *usecase) ListCategory(user *userModel.User) (resp common.Response) {
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 	}
 
-	return resp.CustomResponse(http.StatusOK, "success", data)
+	return resp.CustomResponse(http.StatusOK, "success", buildCategoryTree(data))
 }
 
 func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp common.Response) {
 	db := config.GetDatabase()
 
-	req.UserId = user.ID
+	err := u.resolveFilter(user, req)
+	if err != nil && errors.Is(err, period.ErrInvalidPeriod) {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	} else if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
 	listData, err := u.repo.List(req, db)
 	if err != nil {
 		u.log.Errorf("repo.List: %s", err.Error())
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 	}
 
+	ids := make([]uint, len(listData))
+	for i, data := range listData {
+		ids[i] = data.ID
+	}
+
+	tags, err := u.listTags(ids, db)
+	if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
 	result := make([]model.IncomeData, len(listData))
 	for i, data := range listData {
 		decValue, err := libs.Decrypt(fmt.Sprintf("%d", user.ID), data.Value)
@@ -103,7 +216,7 @@ func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp commo
 			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 		}
 
-		value, err := strconv.ParseFloat(decValue, 64)
+		value, err := libs.ParseMoney(decValue)
 		if err != nil {
 			u.log.Errorf("error parsing string: %s", err.Error())
 			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
@@ -116,14 +229,139 @@ func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp commo
 			Date:       data.Date,
 			Value:      value,
 			Notes:      data.Notes,
+			AccountId:  data.AccountId,
+			Account:    data.Account,
+			Currency:   data.Currency,
+			Tags:       tagsOf(tags, data.ID),
 		}
 	}
 
 	return resp.CustomResponse(http.StatusOK, "success", result)
 }
 
+// Export returns a writer for the incomes matching the list filters as CSV. The
+// rows are read and decrypted one at a time while they are written.
+func (u *usecase) Export(user *userModel.User, req *model.ListRequest) (resp common.Response, write func(w io.Writer) error) {
+	db := config.GetDatabase()
+	key := fmt.Sprintf("%d", user.ID)
+
+	err := u.resolveFilter(user, req)
+	if err != nil && errors.Is(err, period.ErrInvalidPeriod) {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil), nil
+	} else if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil), nil
+	}
+
+	rows, err := u.repo.Export(req, db)
+	if err != nil {
+		u.log.Errorf("repo.Export: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil), nil
+	}
+
+	write = func(w io.Writer) error {
+		defer rows.Close()
+
+		csvWriter := csv.NewWriter(w)
+		err := csvWriter.Write([]string{"date", "category", "value", "currency", "account", "notes"})
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var data model.GetIncome
+			if err := libs.ScanRowIntoStruct(rows, &data); err != nil {
+				return fmt.Errorf("failed to scan row into struct: %w", err)
+			}
+
+			decValue, err := libs.Decrypt(key, data.Value)
+			if err != nil {
+				return fmt.Errorf("error decrypting value: %w", err)
+			}
+
+			value, err := libs.ParseMoney(decValue)
+			if err != nil {
+				return fmt.Errorf("error parsing string: %w", err)
+			}
+
+			var account string
+			if data.Account != nil {
+				account = *data.Account
+			}
+
+			err = csvWriter.Write([]string{
+				libs.ParseDate(data.Date).Format(constant.DateFormat),
+				data.Category,
+				value.String(),
+				data.Currency,
+				account,
+				data.Notes,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return err
+		}
+
+		return rows.Err()
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil), write
+}
+
+// resolveFilter turns the period and category of a list request into the
+// date range and category ids the repository filters on
+func (u *usecase) resolveFilter(user *userModel.User, req *model.ListRequest) error {
+	db := config.GetDatabase()
+
+	if req.Period != "" {
+		startDate, endDate, err := period.ResolveDates(u.periodRepo, user.ID, req.Period, db)
+		if err != nil && errors.Is(err, period.ErrInvalidPeriod) {
+			return err
+		} else if err != nil {
+			return fmt.Errorf("period.ResolveDates: %w", err)
+		}
+
+		req.StartDate = startDate
+		req.EndDate = endDate
+	}
+
+	req.CategoryIds = nil
+	if req.CategoryId != 0 {
+		categoryIds, err := u.repo.ListCategoryDescendant(user.ID, req.CategoryId, db)
+		if err != nil {
+			return fmt.Errorf("repo.ListCategoryDescendant: %w", err)
+		}
+
+		req.CategoryIds = categoryIds
+	}
+
+	req.UserId = user.ID
+
+	return nil
+}
+
 func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response) {
 	db := config.GetDatabase()
+
+	if req.AccountId != nil && *req.AccountId == 0 {
+		req.AccountId = nil
+	}
+
+	if req.AccountId != nil {
+		_, err := u.accountRepo.GetById(user.ID, *req.AccountId, db)
+		if err != nil && errors.Is(err, sql.ErrNoRows) {
+			return resp.CustomResponse(http.StatusNotFound, "account not found", nil)
+		} else if err != nil {
+			u.log.Errorf("accountRepo.GetById: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	}
+
 	tx, err := db.Beginx()
 	if err != nil {
 		u.log.Errorf("error begin tx: %s", err.Error())
@@ -131,7 +369,20 @@ func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp c
 	}
 	defer tx.Rollback()
 
-	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), fmt.Sprintf("%0.f", req.Value))
+	old, err := u.repo.GetForUpdate(user.ID, req.ID, tx)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "income not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetForUpdate: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	// a client that leaves currency out keeps the stored one
+	if req.Currency == "" {
+		req.Currency = old.Currency
+	}
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
 	if err != nil {
 		u.log.Errorf("error encrypting value: %s", err.Error())
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
@@ -142,6 +393,18 @@ func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp c
 		"date":        req.Date,
 		"value":       encValue,
 		"notes":       req.Notes,
+		"currency":    req.Currency,
+	}
+
+	// a client that leaves account_id out keeps the stored account
+	if req.AccountIdSet {
+		data["account_id"] = req.AccountId
+	}
+
+	oldEntry, err := storedEntry(user.ID, old)
+	if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 	}
 
 	err = u.repo.Update(user.ID, req.ID, data, tx)
@@ -150,6 +413,22 @@ func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp c
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 	}
 
+	if req.Tags != nil {
+		if err := u.setTags(user.ID, req.ID, req.Tags, tx); err != nil {
+			u.log.Errorf("%s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	}
+
+	err = u.aggregator.Apply(user.ID, []aggregateModel.Entry{
+		oldEntry,
+		aggregateEntry(req.CategoryId, req.Date, req.Currency, req.Value),
+	}, tx)
+	if err != nil {
+		u.log.Errorf("aggregator.Apply: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
 	if err := tx.Commit(); err != nil {
 		u.log.Errorf("failed commit tx: %s", err.Error())
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
@@ -168,12 +447,32 @@ func (u *usecase) Delete(user *userModel.User, id uint) (resp common.Response) {
 	}
 	defer tx.Rollback()
 
+	old, err := u.repo.GetForUpdate(user.ID, id, tx)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "income not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetForUpdate: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	oldEntry, err := storedEntry(user.ID, old)
+	if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
 	err = u.repo.Delete(user.ID, id, tx)
 	if err != nil {
 		u.log.Errorf("failed delete income: %s", err.Error())
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 	}
 
+	err = u.aggregator.Apply(user.ID, []aggregateModel.Entry{oldEntry}, tx)
+	if err != nil {
+		u.log.Errorf("aggregator.Apply: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
 	if err := tx.Commit(); err != nil {
 		u.log.Errorf("failed commit tx: %s", err.Error())
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
@@ -182,6 +481,83 @@ func (u *usecase) Delete(user *userModel.User, id uint) (resp common.Response) {
 	return resp.CustomResponse(http.StatusOK, "success", nil)
 }
 
+// ResolveDuplicateTx deletes the duplicates of an income inside the given
+// transaction. Every duplicate must have the category, value and currency of
+// the kept income. Merging also copies onto the kept income the notes,
+// account and statement id it is missing, taken from the first duplicate
+// having them.
+func (u *usecase) ResolveDuplicateTx(user *userModel.User, keepId uint, duplicateIds []uint, merge bool, tx *sqlx.Tx) error {
+	kept, err := u.repo.GetForUpdate(user.ID, keepId, tx)
+	if err != nil {
+		return fmt.Errorf("repo.GetForUpdate: %w", err)
+	}
+
+	keptEntry, err := storedEntry(user.ID, kept)
+	if err != nil {
+		return err
+	}
+
+	data := make(map[string]any)
+	entries := make([]aggregateModel.Entry, 0, len(duplicateIds))
+	for _, id := range duplicateIds {
+		duplicate, err := u.repo.GetForUpdate(user.ID, id, tx)
+		if err != nil {
+			return fmt.Errorf("repo.GetForUpdate: %w", err)
+		}
+
+		entry, err := storedEntry(user.ID, duplicate)
+		if err != nil {
+			return err
+		}
+
+		if entry.CategoryId != keptEntry.CategoryId || entry.Currency != keptEntry.Currency || entry.Value.Cmp(keptEntry.Value) != 0 {
+			return cashflowModel.ErrNotDuplicate
+		}
+
+		entries = append(entries, entry)
+
+		if !merge {
+			continue
+		}
+
+		if _, ok := data["notes"]; !ok && kept.Notes == "" && duplicate.Notes != "" {
+			data["notes"] = duplicate.Notes
+		}
+
+		if _, ok := data["account_id"]; !ok && kept.AccountId == nil && duplicate.AccountId != nil {
+			data["account_id"] = *duplicate.AccountId
+		}
+
+		if _, ok := data["external_id"]; !ok && kept.ExternalId == nil && duplicate.ExternalId != nil {
+			data["external_id"] = *duplicate.ExternalId
+		}
+	}
+
+	// the tags of the duplicates are dropped with them, so they are copied
+	// onto the kept income first
+	if merge {
+		if err := u.mergeTags(keepId, duplicateIds, tx); err != nil {
+			return err
+		}
+	}
+
+	// duplicates go first, the statement id moved onto the kept income is
+	// unique per user
+	for _, id := range duplicateIds {
+		if err := u.repo.Delete(user.ID, id, tx); err != nil {
+			return fmt.Errorf("failed delete income: %w", err)
+		}
+	}
+
+	if len(data) > 0 {
+		if err := u.repo.Update(user.ID, keepId, data, tx); err != nil {
+			return fmt.Errorf("failed update income: %w", err)
+		}
+	}
+
+	return u.aggregator.Apply(user.ID, entries, tx)
+}
+
 func (u *usecase) GetById(user *userModel.User, id uint) (resp common.Response) {
 	db := config.GetDatabase()
 
@@ -197,12 +573,18 @@ func (u *usecase) GetById(user *userModel.User, id uint) (resp common.Response)
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 	}
 
-	value, err := strconv.ParseFloat(decValue, 64)
+	value, err := libs.ParseMoney(decValue)
 	if err != nil {
 		u.log.Errorf("error parsing string: %s", err.Error())
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 	}
 
+	tags, err := u.listTags([]uint{data.ID}, db)
+	if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
 	result := model.IncomeData{
 		ID:         data.ID,
 		CategoryId: data.CategoryId,
@@ -210,7 +592,405 @@ func (u *usecase) GetById(user *userModel.User, id uint) (resp common.Response)
 		Date:       data.Date,
 		Value:      value,
 		Notes:      data.Notes,
+		AccountId:  data.AccountId,
+		Account:    data.Account,
+		Currency:   data.Currency,
+		Tags:       tagsOf(tags, data.ID),
 	}
 
 	return resp.CustomResponse(http.StatusOK, "success", result)
 }
+
+func (u *usecase) AddCategory(user *userModel.User, req *model.CategoryRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	if req.ParentId != nil {
+		_, err := u.repo.GetCategoryById(user.ID, *req.ParentId, db)
+		if err != nil && errors.Is(err, sql.ErrNoRows) {
+			return resp.CustomResponse(http.StatusNotFound, "parent category not found", nil)
+		} else if err != nil {
+			u.log.Errorf("repo.GetCategoryById: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	data := model.IncomeCategory{
+		Name:     req.Name,
+		ParentId: req.ParentId,
+		UserId:   user.ID,
+	}
+
+	err = u.repo.InsertCategory(&data, tx)
+	if err != nil {
+		u.log.Errorf("failed insert income category: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", nil)
+}
+
+func (u *usecase) UpdateCategory(user *userModel.User, req *model.CategoryRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	_, err := u.repo.GetCategoryById(user.ID, req.ID, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "category not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetCategoryById: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if req.ParentId != nil {
+		_, err := u.repo.GetCategoryById(user.ID, *req.ParentId, db)
+		if err != nil && errors.Is(err, sql.ErrNoRows) {
+			return resp.CustomResponse(http.StatusNotFound, "parent category not found", nil)
+		} else if err != nil {
+			u.log.Errorf("repo.GetCategoryById: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		descendantIds, err := u.repo.ListCategoryDescendant(user.ID, req.ID, db)
+		if err != nil {
+			u.log.Errorf("repo.ListCategoryDescendant: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		if slices.Contains(descendantIds, *req.ParentId) {
+			return resp.CustomResponse(http.StatusBadRequest, "category cannot be moved under itself or its sub-category", nil)
+		}
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	data := map[string]any{
+		"name": req.Name,
+	}
+	if req.ParentIdSet {
+		data["parent_id"] = req.ParentId
+	}
+
+	err = u.repo.UpdateCategory(user.ID, req.ID, data, tx)
+	if err != nil {
+		u.log.Errorf("failed update income category: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) DeleteCategory(user *userModel.User, req *model.DeleteCategoryRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	_, err := u.repo.GetCategoryById(user.ID, req.ID, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "category not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetCategoryById: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	descendantIds, err := u.repo.ListCategoryDescendant(user.ID, req.ID, db)
+	if err != nil {
+		u.log.Errorf("repo.ListCategoryDescendant: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if len(descendantIds) > 1 {
+		return resp.CustomResponse(http.StatusBadRequest, "category still has sub-categories, move or delete them first", nil)
+	}
+
+	if req.ReassignTo != 0 {
+		if req.ReassignTo == req.ID {
+			return resp.CustomResponse(http.StatusBadRequest, "cannot reassign transactions to the deleted category", nil)
+		}
+
+		_, err := u.repo.GetCategoryById(user.ID, req.ReassignTo, db)
+		if err != nil && errors.Is(err, sql.ErrNoRows) {
+			return resp.CustomResponse(http.StatusNotFound, "reassign target category not found", nil)
+		} else if err != nil {
+			u.log.Errorf("repo.GetCategoryById: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	if req.ReassignTo != 0 {
+		err = u.repo.ReassignCategory(user.ID, []uint{req.ID}, req.ReassignTo, tx)
+		if err != nil {
+			u.log.Errorf("failed reassign income category: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		err = u.aggregator.Invalidate(user.ID, tx)
+		if err != nil {
+			u.log.Errorf("aggregator.Invalidate: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	} else {
+		total, err := u.repo.CountByCategory(user.ID, req.ID, tx)
+		if err != nil {
+			u.log.Errorf("repo.CountByCategory: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		if total > 0 {
+			message := fmt.Sprintf("category is still used by %d income(s) or rule(s), reassign them to another category before deleting", total)
+			return resp.CustomResponse(http.StatusBadRequest, message, nil)
+		}
+	}
+
+	err = u.repo.DeleteCategory(user.ID, []uint{req.ID}, tx)
+	if err != nil {
+		u.log.Errorf("failed delete income category: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+// MergeCategory moves every income of the source categories into the target
+// category and removes the sources afterwards
+func (u *usecase) MergeCategory(user *userModel.User, req *model.MergeCategoryRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	categories, err := u.repo.ListCategory(user.ID, db)
+	if err != nil {
+		u.log.Errorf("repo.ListCategory: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	ownedIds := make(map[uint]struct{}, len(categories))
+	for _, category := range categories {
+		ownedIds[category.ID] = struct{}{}
+	}
+
+	parents := categoryParents(categories)
+
+	if _, ok := ownedIds[req.TargetId]; !ok {
+		return resp.CustomResponse(http.StatusNotFound, "target category not found", nil)
+	}
+
+	for _, id := range req.SourceIds {
+		if id == req.TargetId {
+			return resp.CustomResponse(http.StatusBadRequest, "target category cannot be one of the source categories", nil)
+		}
+
+		if _, ok := ownedIds[id]; !ok {
+			return resp.CustomResponse(http.StatusNotFound, fmt.Sprintf("source category %d not found", id), nil)
+		}
+
+		if slices.Contains(libs.Descendants(id, parents), req.TargetId) {
+			return resp.CustomResponse(http.StatusBadRequest, "target category cannot be a sub-category of the source categories", nil)
+		}
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	err = u.repo.ReassignCategory(user.ID, req.SourceIds, req.TargetId, tx)
+	if err != nil {
+		u.log.Errorf("failed reassign income category: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	err = u.aggregator.Invalidate(user.ID, tx)
+	if err != nil {
+		u.log.Errorf("aggregator.Invalidate: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	err = u.repo.ReparentCategory(user.ID, req.SourceIds, req.TargetId, tx)
+	if err != nil {
+		u.log.Errorf("failed reparent income category: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	err = u.repo.DeleteCategory(user.ID, req.SourceIds, tx)
+	if err != nil {
+		u.log.Errorf("failed delete income category: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+// aggregateEntry is the change a income adds to the cashflow aggregates
+func aggregateEntry(categoryId uint, date interface{}, currency string, value libs.Money) aggregateModel.Entry {
+	return aggregateModel.Entry{
+		Type:       "income",
+		CategoryId: categoryId,
+		Date:       libs.ParseDate(date),
+		Currency:   currency,
+		Value:      value,
+	}
+}
+
+// storedEntry is the change that takes a stored income out of the aggregates
+func storedEntry(userId uint, data model.Income) (aggregateModel.Entry, error) {
+	decValue, err := libs.Decrypt(fmt.Sprintf("%d", userId), data.Value)
+	if err != nil {
+		return aggregateModel.Entry{}, fmt.Errorf("error decrypting value: %w", err)
+	}
+
+	value, err := libs.ParseMoney(decValue)
+	if err != nil {
+		return aggregateModel.Entry{}, fmt.Errorf("error parsing string: %w", err)
+	}
+
+	return aggregateEntry(data.CategoryId, data.Date, data.Currency, value.Neg()), nil
+}
+
+// buildCategoryTree nests every category under its parent, top level
+// categories are returned in the order they were listed
+func buildCategoryTree(categories []model.IncomeCategory) []model.IncomeCategory {
+	ownedIds := make(map[uint]struct{}, len(categories))
+	for _, category := range categories {
+		ownedIds[category.ID] = struct{}{}
+	}
+
+	roots := make([]model.IncomeCategory, 0)
+	children := make(map[uint][]model.IncomeCategory)
+	for _, category := range categories {
+		if category.ParentId == nil {
+			roots = append(roots, category)
+			continue
+		}
+
+		if _, ok := ownedIds[*category.ParentId]; !ok {
+			roots = append(roots, category)
+			continue
+		}
+
+		children[*category.ParentId] = append(children[*category.ParentId], category)
+	}
+
+	var build func(category model.IncomeCategory) model.IncomeCategory
+	build = func(category model.IncomeCategory) model.IncomeCategory {
+		category.Children = make([]model.IncomeCategory, len(children[category.ID]))
+		for i, child := range children[category.ID] {
+			category.Children[i] = build(child)
+		}
+
+		return category
+	}
+
+	for i, root := range roots {
+		roots[i] = build(root)
+	}
+
+	return roots
+}
+
+// mergeTags gives the kept income the union of its tags and the tags of the
+// duplicates
+func (u *usecase) mergeTags(keepId uint, duplicateIds []uint, tx *sqlx.Tx) error {
+	tags, err := u.repo.ListTag(append([]uint{keepId}, duplicateIds...), config.GetDatabase())
+	if err != nil {
+		return fmt.Errorf("repo.ListTag: %w", err)
+	}
+
+	var added bool
+	seen := make(map[uint]struct{})
+	tagIds := make([]uint, 0, len(tags))
+	for _, t := range tags {
+		if _, ok := seen[t.ID]; ok {
+			continue
+		}
+
+		seen[t.ID] = struct{}{}
+		tagIds = append(tagIds, t.ID)
+		added = added || t.TransactionId != keepId
+	}
+
+	if !added {
+		return nil
+	}
+
+	err = u.repo.ReplaceTag(keepId, tagIds, tx)
+	if err != nil {
+		return fmt.Errorf("repo.ReplaceTag: %w", err)
+	}
+
+	return nil
+}
+
+// setTags replaces the tags of the income with the named ones, creating the
+// tags the user does not have yet
+func (u *usecase) setTags(userId, incomeId uint, names []string, tx *sqlx.Tx) error {
+	tagIds, err := u.tagRepo.Ensure(userId, tag.NormalizeNames(names), tx)
+	if err != nil {
+		return fmt.Errorf("tagRepo.Ensure: %w", err)
+	}
+
+	err = u.repo.ReplaceTag(incomeId, tagIds, tx)
+	if err != nil {
+		return fmt.Errorf("repo.ReplaceTag: %w", err)
+	}
+
+	return nil
+}
+
+// listTags groups the tags of the given incomes by income id
+func (u *usecase) listTags(incomeIds []uint, db *sqlx.DB) (map[uint][]tagModel.Tag, error) {
+	data, err := u.repo.ListTag(incomeIds, db)
+	if err != nil {
+		return nil, fmt.Errorf("repo.ListTag: %w", err)
+	}
+
+	result := make(map[uint][]tagModel.Tag)
+	for _, t := range data {
+		result[t.TransactionId] = append(result[t.TransactionId], tagModel.Tag{ID: t.ID, Name: t.Name})
+	}
+
+	return result, nil
+}
+
+func tagsOf(tags map[uint][]tagModel.Tag, incomeId uint) []tagModel.Tag {
+	if result, ok := tags[incomeId]; ok {
+		return result
+	}
+
+	return []tagModel.Tag{}
+}
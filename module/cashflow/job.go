@@ -0,0 +1,7 @@
+package cashflow
+
+import "github.com/fazriegi/money_management-be/module/cashflow/recurring"
+
+func NewJob() {
+	recurring.NewJob()
+}
@@ -1,32 +1,55 @@
 package model
 
-import "github.com/fazriegi/money_management-be/module/common"
+import (
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/common"
+)
 
 type GetCashflow struct {
-	ID       uint        `db:"id"`
-	Category string      `db:"category"`
-	Date     interface{} `db:"date"`
-	Value    string      `db:"value"`
-	UserId   uint        `db:"user_id"`
-	Type     string      `db:"type"`
+	ID        uint        `db:"id"`
+	Category  string      `db:"category"`
+	Date      interface{} `db:"date"`
+	Value     string      `db:"value"`
+	UserId    uint        `db:"user_id"`
+	Type      string      `db:"type"`
+	AccountId *uint       `db:"account_id"`
+	Account   *string     `db:"account"`
+	Currency  *string     `db:"currency"`
+	Notes     string      `db:"notes"`
 }
 
+const (
+	TypeIncome   = "income"
+	TypeExpense  = "expense"
+	TypeTransfer = "transfer"
+)
+
 type ListFilter struct {
-	UserId    uint
-	StartDate string `query:"start_date"`
-	EndDate   string `query:"end_date"`
+	UserId      uint
+	StartDate   string `query:"start_date"`
+	EndDate     string `query:"end_date"`
+	CategoryIds []uint
+	AccountId   uint   `query:"account_id"`
+	TagIds      []uint `query:"tag_id"`
+	Currency    string
 }
 
 type ListRequest struct {
 	common.PaginationRequest
 	ListFilter
-	Category string `query:"category"`
+	Category   string `query:"category"`
+	Period     string `query:"period"`
+	Type       string `query:"type" validate:"required_with=CategoryId,omitempty,oneof=income expense transfer"`
+	CategoryId uint   `query:"category_id"`
 }
 
 type CashflowData struct {
-	ID       uint        `json:"id"`
-	Category string      `json:"category"`
-	Date     interface{} `json:"date"`
-	Value    float64     `json:"value"`
-	Type     string      `json:"type"`
+	ID        uint        `json:"id"`
+	Category  string      `json:"category"`
+	Date      interface{} `json:"date"`
+	Value     libs.Money  `json:"value"`
+	Type      string      `json:"type"`
+	AccountId *uint       `json:"account_id"`
+	Account   *string     `json:"account"`
+	Currency  *string     `json:"currency"`
 }
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/fazriegi/money_management-be/libs"
+	periodModel "github.com/fazriegi/money_management-be/module/master/period/model"
+)
+
+const (
+	ResolveDelete = "delete"
+	ResolveMerge  = "merge"
+)
+
+var ErrNotDuplicate = errors.New("transactions are not duplicates of the kept one")
+
+// DuplicateRequest looks for duplicates dated within the period. Days is how
+// far apart the dates of two duplicates may be, 3 when not given.
+type DuplicateRequest struct {
+	Period string `query:"period"`
+	Days   *uint  `query:"days" validate:"omitempty,max=31"`
+	Type   string `query:"type" validate:"omitempty,oneof=income expense"`
+}
+
+type GetTransaction struct {
+	ID         uint        `db:"id"`
+	Type       string      `db:"type"`
+	CategoryId uint        `db:"category_id"`
+	Category   string      `db:"category"`
+	Date       interface{} `db:"date"`
+	Value      string      `db:"value"`
+	Currency   string      `db:"currency"`
+	AccountId  *uint       `db:"account_id"`
+	Account    *string     `db:"account"`
+	Notes      string      `db:"notes"`
+}
+
+type DuplicateTransaction struct {
+	ID        uint        `json:"id"`
+	Date      interface{} `json:"date"`
+	Notes     string      `json:"notes"`
+	AccountId *uint       `json:"account_id"`
+	Account   *string     `json:"account"`
+}
+
+// DuplicateGroup is a set of transactions of the same type, category, value
+// and currency that are likely the same entry recorded more than once
+type DuplicateGroup struct {
+	Type         string                 `json:"type"`
+	CategoryId   uint                   `json:"category_id"`
+	Category     string                 `json:"category"`
+	Value        libs.Money             `json:"value"`
+	Currency     string                 `json:"currency"`
+	Transactions []DuplicateTransaction `json:"transactions"`
+}
+
+type Duplicates struct {
+	Period periodModel.PeriodRange `json:"period"`
+	Days   uint                    `json:"days"`
+	Groups []DuplicateGroup        `json:"groups"`
+}
+
+// ResolveDuplicateRequest keeps one transaction and deletes its duplicates.
+// Merging first copies onto the kept transaction the notes, account and
+// statement id it is missing.
+type ResolveDuplicateRequest struct {
+	Type         string `json:"type" validate:"required,oneof=income expense"`
+	KeepId       uint   `json:"keep_id" validate:"required"`
+	DuplicateIds []uint `json:"duplicate_ids" validate:"required,min=1,dive,required"`
+	Action       string `json:"action" validate:"required,oneof=delete merge"`
+}
@@ -0,0 +1,13 @@
+package recurring
+
+import (
+	"time"
+
+	"github.com/fazriegi/money_management-be/libs"
+)
+
+func NewJob() {
+	usecase := newUsecase()
+
+	go libs.RunEvery(time.Hour, usecase.Generate)
+}
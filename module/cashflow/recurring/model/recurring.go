@@ -0,0 +1,86 @@
+package model
+
+import (
+	"github.com/fazriegi/money_management-be/libs"
+	"time"
+)
+
+const (
+	TypeIncome  = "income"
+	TypeExpense = "expense"
+
+	FrequencyDaily       = "daily"
+	FrequencyWeekly      = "weekly"
+	FrequencyMonthly     = "monthly"
+	FrequencyYearly      = "yearly"
+	FrequencyPeriodStart = "period_start"
+)
+
+type Recurring struct {
+	ID         uint    `db:"id"`
+	Type       string  `db:"type"`
+	CategoryId uint    `db:"category_id"`
+	Frequency  string  `db:"frequency"`
+	StartDate  string  `db:"start_date"`
+	EndDate    *string `db:"end_date"`
+	Value      string  `db:"value"`
+	Currency   string  `db:"currency"`
+	Notes      string  `db:"notes"`
+	UserId     uint    `db:"user_id"`
+}
+
+type GetRecurring struct {
+	ID                uint       `db:"id"`
+	Type              string     `db:"type"`
+	CategoryId        uint       `db:"category_id"`
+	Category          string     `db:"category"`
+	Frequency         string     `db:"frequency"`
+	StartDate         time.Time  `db:"start_date"`
+	EndDate           *time.Time `db:"end_date"`
+	Value             string     `db:"value"`
+	Currency          string     `db:"currency"`
+	Notes             string     `db:"notes"`
+	LastGeneratedDate *time.Time `db:"last_generated_date"`
+	UserId            uint       `db:"user_id"`
+}
+
+type RecurringData struct {
+	ID                uint       `json:"id"`
+	Type              string     `json:"type"`
+	CategoryId        uint       `json:"category_id"`
+	Category          string     `json:"category"`
+	Frequency         string     `json:"frequency"`
+	StartDate         time.Time  `json:"start_date"`
+	EndDate           *time.Time `json:"end_date"`
+	Value             libs.Money `json:"value"`
+	Currency          string     `json:"currency"`
+	Notes             string     `json:"notes"`
+	LastGeneratedDate *time.Time `json:"last_generated_date"`
+}
+
+type AddRequest struct {
+	Type       string     `json:"type" validate:"required,oneof=income expense"`
+	CategoryId uint       `json:"category_id" validate:"required"`
+	Frequency  string     `json:"frequency" validate:"required,oneof=daily weekly monthly yearly period_start"`
+	StartDate  string     `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate    string     `json:"end_date" validate:"omitempty,datetime=2006-01-02"`
+	Value      libs.Money `json:"value" validate:"required"`
+	Currency   string     `json:"currency" validate:"omitempty,iso4217"`
+	Notes      string     `json:"notes"`
+}
+
+type ListRequest struct {
+	Type   string `query:"type" validate:"omitempty,oneof=income expense"`
+	UserId uint
+}
+
+type UpdateRequest struct {
+	ID         uint
+	CategoryId uint       `json:"category_id" validate:"required"`
+	Frequency  string     `json:"frequency" validate:"required,oneof=daily weekly monthly yearly period_start"`
+	StartDate  string     `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate    string     `json:"end_date" validate:"omitempty,datetime=2006-01-02"`
+	Value      libs.Money `json:"value" validate:"required"`
+	Currency   string     `json:"currency" validate:"omitempty,iso4217"`
+	Notes      string     `json:"notes"`
+}
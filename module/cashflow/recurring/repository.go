@@ -0,0 +1,242 @@
+package recurring
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/recurring/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Insert(data *model.Recurring, tx *sqlx.Tx) (result uint, err error)
+	List(req *model.ListRequest, db *sqlx.DB) (result []model.GetRecurring, err error)
+	GetById(userId, id uint, db *sqlx.DB) (result model.GetRecurring, err error)
+	Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error
+	Delete(userId, id uint, tx *sqlx.Tx) error
+	ListDue(date string, db *sqlx.DB) (result []model.GetRecurring, err error)
+	UpdateLastGenerated(id uint, date string, tx *sqlx.Tx) error
+	ListGeneratedDate(id uint, recurringType string, tx *sqlx.Tx) (result []time.Time, err error)
+}
+
+type repository struct{}
+
+func NewRepository() Repository {
+	return &repository{}
+}
+
+func (r *repository) Insert(data *model.Recurring, tx *sqlx.Tx) (result uint, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("recurring").Rows(*data)
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	res, err := tx.Exec(sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return result, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return uint(id), nil
+}
+
+func (r *repository) List(req *model.ListRequest, db *sqlx.DB) (result []model.GetRecurring, err error) {
+	dataset := selectQuery().
+		Where(goqu.I("r.user_id").Eq(req.UserId)).
+		Order(goqu.I("r.start_date").Desc())
+
+	if req.Type != "" {
+		dataset = dataset.Where(goqu.I("r.type").Eq(req.Type))
+	}
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.GetRecurring, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) GetById(userId, id uint, db *sqlx.DB) (result model.GetRecurring, err error) {
+	dataset := selectQuery().
+		Where(
+			goqu.I("r.user_id").Eq(userId),
+			goqu.I("r.id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("recurring").
+		Set(data).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(userId, id uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Delete("recurring").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	return nil
+}
+
+// ListDue returns the rules of every user that may still have occurrences
+// to generate up to the given date
+func (r *repository) ListDue(date string, db *sqlx.DB) (result []model.GetRecurring, err error) {
+	dataset := selectQuery().
+		Where(
+			goqu.I("r.start_date").Lte(date),
+			goqu.Or(
+				goqu.I("r.last_generated_date").IsNull(),
+				goqu.I("r.last_generated_date").Lt(date),
+			),
+			goqu.Or(
+				goqu.I("r.end_date").IsNull(),
+				goqu.I("r.last_generated_date").IsNull(),
+				goqu.I("r.last_generated_date").Lt(goqu.I("r.end_date")),
+			),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.GetRecurring, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) UpdateLastGenerated(id uint, date string, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("recurring").
+		Set(goqu.Record{"last_generated_date": date}).
+		Where(goqu.I("id").Eq(id))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+// ListGeneratedDate returns the dates of the incomes or expenses the rule has
+// generated so far
+func (r *repository) ListGeneratedDate(id uint, recurringType string, tx *sqlx.Tx) (result []time.Time, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From(recurringType).
+		Select(goqu.I("date")).
+		Where(goqu.I("recurring_id").Eq(id))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]time.Time, 0)
+	err = tx.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+// selectQuery joins a rule with the category table matching its type
+func selectQuery() *goqu.SelectDataset {
+	dialect := libs.GetDialect()
+
+	return dialect.
+		From(goqu.T("recurring").As("r")).
+		LeftJoin(goqu.T("user_income_category").As("uic"), goqu.On(
+			goqu.I("uic.id").Eq(goqu.I("r.category_id")),
+			goqu.I("r.type").Eq(model.TypeIncome),
+		)).
+		LeftJoin(goqu.T("user_expense_category").As("uec"), goqu.On(
+			goqu.I("uec.id").Eq(goqu.I("r.category_id")),
+			goqu.I("r.type").Eq(model.TypeExpense),
+		)).
+		Select(
+			goqu.I("r.id"),
+			goqu.I("r.type"),
+			goqu.I("r.category_id"),
+			goqu.COALESCE(goqu.I("uic.name"), goqu.I("uec.name"), "").As("category"),
+			goqu.I("r.frequency"),
+			goqu.I("r.start_date"),
+			goqu.I("r.end_date"),
+			goqu.I("r.value"),
+			goqu.I("r.currency"),
+			goqu.COALESCE(goqu.I("r.notes"), "").As("notes"),
+			goqu.I("r.last_generated_date"),
+			goqu.I("r.user_id"),
+		)
+}
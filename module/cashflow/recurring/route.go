@@ -0,0 +1,46 @@
+package recurring
+
+import (
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/middleware"
+	"github.com/fazriegi/money_management-be/module/cashflow/aggregate"
+	"github.com/fazriegi/money_management-be/module/cashflow/expense"
+	"github.com/fazriegi/money_management-be/module/cashflow/income"
+	"github.com/fazriegi/money_management-be/module/cashflow/rule"
+	"github.com/fazriegi/money_management-be/module/master/account"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	"github.com/fazriegi/money_management-be/module/master/tag"
+	"github.com/gofiber/fiber/v2"
+)
+
+func NewRoute(app *fiber.App, jwt *libs.JWT) {
+	log := config.GetLogger()
+	usecase := newUsecase()
+	controller := NewController(log, usecase)
+
+	route := app.Group("/recurring")
+	route.Post("/", middleware.Authentication(jwt), controller.Add)
+	route.Get("/", middleware.Authentication(jwt), controller.List)
+	route.Get("/:id", middleware.Authentication(jwt), controller.GetById)
+	route.Put("/:id", middleware.Authentication(jwt), controller.Update)
+	route.Delete("/:id", middleware.Authentication(jwt), controller.Delete)
+}
+
+func newUsecase() Usecase {
+	log := config.GetLogger()
+	repo := NewRepository()
+	incomeRepo := income.NewRepository()
+	expenseRepo := expense.NewRepository()
+	periodRepo := period.NewRepository()
+	accountRepo := account.NewRepository()
+	currencyRepo := currency.NewRepository()
+	aggregator := aggregate.NewAggregator(aggregate.NewRepository())
+	tagRepo := tag.NewRepository()
+	matcher := rule.NewMatcher(rule.NewRepository())
+	incomeUsecase := income.NewUsecase(log, incomeRepo, periodRepo, accountRepo, currencyRepo, tagRepo, aggregator, matcher)
+	expenseUsecase := expense.NewUsecase(log, expenseRepo, periodRepo, accountRepo, currencyRepo, tagRepo, aggregator, matcher)
+
+	return NewUsecase(log, repo, incomeRepo, expenseRepo, periodRepo, currencyRepo, incomeUsecase, expenseUsecase)
+}
@@ -0,0 +1,61 @@
+package recurring
+
+import (
+	"time"
+
+	"github.com/fazriegi/money_management-be/module/cashflow/recurring/model"
+	"github.com/fazriegi/money_management-be/module/master/period"
+)
+
+// occurrences returns the dates between from and to, both inclusive, on
+// which the rule is due. Every occurrence is computed from the start date,
+// so monthly rules starting on the 31st don't drift after a short month.
+func occurrences(rule *model.GetRecurring, dayOfMonth uint8, from, to time.Time) []time.Time {
+	result := make([]time.Time, 0)
+
+	for n := 0; ; n++ {
+		date, ok := nthOccurrence(rule, dayOfMonth, n)
+		if !ok || date.After(to) {
+			break
+		}
+
+		if !date.Before(from) {
+			result = append(result, date)
+		}
+	}
+
+	return result
+}
+
+func nthOccurrence(rule *model.GetRecurring, dayOfMonth uint8, n int) (time.Time, bool) {
+	start := rule.StartDate
+
+	switch rule.Frequency {
+	case model.FrequencyDaily:
+		return start.AddDate(0, 0, n), true
+	case model.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*n), true
+	case model.FrequencyMonthly:
+		return addMonths(start, n), true
+	case model.FrequencyYearly:
+		return addMonths(start, 12*n), true
+	case model.FrequencyPeriodStart:
+		first := period.GetRangeOf(dayOfMonth, start)
+		if first.StartDate.Before(start) {
+			n++
+		}
+
+		return period.Shift(dayOfMonth, first, n).StartDate, true
+	}
+
+	return time.Time{}, false
+}
+
+// addMonths moves date n months ahead, clamping the day to the length of the
+// target month instead of overflowing into the next one
+func addMonths(date time.Time, n int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(n), 1, 0, 0, 0, 0, time.Local)
+	lastDay := first.AddDate(0, 1, -1).Day()
+
+	return time.Date(first.Year(), first.Month(), min(date.Day(), lastDay), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,166 @@
+package recurring
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/module/cashflow/recurring/model"
+)
+
+func mustDate(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	result, err := time.ParseInLocation(constant.DateFormat, value, time.Local)
+	if err != nil {
+		t.Fatalf("invalid date %q: %v", value, err)
+	}
+
+	return result
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name       string
+		frequency  string
+		startDate  string
+		dayOfMonth uint8
+		from       string
+		to         string
+		want       []string
+	}{
+		{
+			name:      "daily",
+			frequency: model.FrequencyDaily,
+			startDate: "2026-02-27",
+			from:      "2026-02-27",
+			to:        "2026-03-02",
+			want:      []string{"2026-02-27", "2026-02-28", "2026-03-01", "2026-03-02"},
+		},
+		{
+			name:      "weekly",
+			frequency: model.FrequencyWeekly,
+			startDate: "2026-01-01",
+			from:      "2026-01-01",
+			to:        "2026-01-29",
+			want:      []string{"2026-01-01", "2026-01-08", "2026-01-15", "2026-01-22", "2026-01-29"},
+		},
+		{
+			name:      "monthly on the 31st clamps to february",
+			frequency: model.FrequencyMonthly,
+			startDate: "2026-01-31",
+			from:      "2026-01-31",
+			to:        "2026-05-31",
+			want:      []string{"2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30", "2026-05-31"},
+		},
+		{
+			name:      "monthly on the 31st clamps to a leap february",
+			frequency: model.FrequencyMonthly,
+			startDate: "2028-01-31",
+			from:      "2028-01-31",
+			to:        "2028-03-31",
+			want:      []string{"2028-01-31", "2028-02-29", "2028-03-31"},
+		},
+		{
+			name:      "monthly on the 30th does not drift after february",
+			frequency: model.FrequencyMonthly,
+			startDate: "2026-01-30",
+			from:      "2026-02-01",
+			to:        "2026-04-30",
+			want:      []string{"2026-02-28", "2026-03-30", "2026-04-30"},
+		},
+		{
+			name:      "yearly on a leap day",
+			frequency: model.FrequencyYearly,
+			startDate: "2024-02-29",
+			from:      "2024-02-29",
+			to:        "2028-02-29",
+			want:      []string{"2024-02-29", "2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"},
+		},
+		{
+			name:       "period start after the start date",
+			frequency:  model.FrequencyPeriodStart,
+			startDate:  "2026-01-10",
+			dayOfMonth: 25,
+			from:       "2026-01-10",
+			to:         "2026-03-31",
+			want:       []string{"2026-01-25", "2026-02-25", "2026-03-25"},
+		},
+		{
+			name:       "period start clamps to a short month",
+			frequency:  model.FrequencyPeriodStart,
+			startDate:  "2026-01-31",
+			dayOfMonth: 31,
+			from:       "2026-01-31",
+			to:         "2026-03-31",
+			want:       []string{"2026-01-31", "2026-02-28", "2026-03-31"},
+		},
+		{
+			name:      "from and to are inclusive",
+			frequency: model.FrequencyMonthly,
+			startDate: "2026-01-15",
+			from:      "2026-02-15",
+			to:        "2026-03-15",
+			want:      []string{"2026-02-15", "2026-03-15"},
+		},
+		{
+			name:      "nothing due before the start date",
+			frequency: model.FrequencyMonthly,
+			startDate: "2026-06-01",
+			from:      "2026-01-01",
+			to:        "2026-05-31",
+			want:      []string{},
+		},
+		{
+			name:      "unknown frequency",
+			frequency: "hourly",
+			startDate: "2026-01-01",
+			from:      "2026-01-01",
+			to:        "2026-12-31",
+			want:      []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &model.GetRecurring{
+				Frequency: tt.frequency,
+				StartDate: mustDate(t, tt.startDate),
+			}
+
+			got := make([]string, 0)
+			for _, occurrence := range occurrences(rule, tt.dayOfMonth, mustDate(t, tt.from), mustDate(t, tt.to)) {
+				got = append(got, occurrence.Format(constant.DateFormat))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("occurrences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		date string
+		n    int
+		want string
+	}{
+		{"2026-01-31", 1, "2026-02-28"},
+		{"2028-01-31", 1, "2028-02-29"},
+		{"2026-03-31", -1, "2026-02-28"},
+		{"2026-08-31", 1, "2026-09-30"},
+		{"2026-12-31", 2, "2027-02-28"},
+		{"2026-05-15", 12, "2027-05-15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			got := addMonths(mustDate(t, tt.date), tt.n).Format(constant.DateFormat)
+			if got != tt.want {
+				t.Errorf("addMonths(%s, %d) = %s, want %s", tt.date, tt.n, got, tt.want)
+			}
+		})
+	}
+}
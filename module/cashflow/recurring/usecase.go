@@ -0,0 +1,457 @@
+package recurring
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/expense"
+	expenseModel "github.com/fazriegi/money_management-be/module/cashflow/expense/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/income"
+	incomeModel "github.com/fazriegi/money_management-be/module/cashflow/income/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/recurring/model"
+	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type Usecase interface {
+	Add(user *userModel.User, req *model.AddRequest) (resp common.Response)
+	List(user *userModel.User, req *model.ListRequest) (resp common.Response)
+	GetById(user *userModel.User, id uint) (resp common.Response)
+	Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response)
+	Delete(user *userModel.User, id uint) (resp common.Response)
+	Generate()
+}
+
+type usecase struct {
+	log            *logrus.Logger
+	repo           Repository
+	incomeRepo     income.Repository
+	expenseRepo    expense.Repository
+	periodRepo     period.Repository
+	currencyRepo   currency.Repository
+	incomeUsecase  income.Usecase
+	expenseUsecase expense.Usecase
+}
+
+func NewUsecase(
+	log *logrus.Logger,
+	repo Repository,
+	incomeRepo income.Repository,
+	expenseRepo expense.Repository,
+	periodRepo period.Repository,
+	currencyRepo currency.Repository,
+	incomeUsecase income.Usecase,
+	expenseUsecase expense.Usecase,
+) Usecase {
+	return &usecase{
+		log,
+		repo,
+		incomeRepo,
+		expenseRepo,
+		periodRepo,
+		currencyRepo,
+		incomeUsecase,
+		expenseUsecase,
+	}
+}
+
+func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	if req.EndDate != "" && req.EndDate < req.StartDate {
+		return resp.CustomResponse(http.StatusBadRequest, "end_date must not be before start_date", nil)
+	}
+
+	err := u.checkCategory(user.ID, req.Type, req.CategoryId, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "category not found", nil)
+	} else if err != nil {
+		u.log.Errorf("checkCategory: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if req.Currency == "" {
+		base, err := u.currencyRepo.GetBaseCurrency(user.ID, db)
+		if err != nil {
+			u.log.Errorf("currencyRepo.GetBaseCurrency: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		req.Currency = base
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	data := model.Recurring{
+		Type:       req.Type,
+		CategoryId: req.CategoryId,
+		Frequency:  req.Frequency,
+		StartDate:  req.StartDate,
+		EndDate:    nullableDate(req.EndDate),
+		Value:      encValue,
+		Currency:   req.Currency,
+		Notes:      req.Notes,
+		UserId:     user.ID,
+	}
+
+	id, err := u.repo.Insert(&data, tx)
+	if err != nil {
+		u.log.Errorf("failed insert recurring: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	// rules starting in the past are caught up right away, the scheduler
+	// retries on its next run if this fails
+	if err := u.generateById(user.ID, id, db); err != nil {
+		u.log.Errorf("failed generate recurring %d: %s", id, err.Error())
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", nil)
+}
+
+func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	req.UserId = user.ID
+	listData, err := u.repo.List(req, db)
+	if err != nil {
+		u.log.Errorf("repo.List: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	result := make([]model.RecurringData, len(listData))
+	for i, data := range listData {
+		result[i], err = toRecurringData(user.ID, &data)
+		if err != nil {
+			u.log.Errorf("toRecurringData: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+func (u *usecase) GetById(user *userModel.User, id uint) (resp common.Response) {
+	db := config.GetDatabase()
+
+	data, err := u.repo.GetById(user.ID, id, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "recurring not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetById: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	result, err := toRecurringData(user.ID, &data)
+	if err != nil {
+		u.log.Errorf("toRecurringData: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	if req.EndDate != "" && req.EndDate < req.StartDate {
+		return resp.CustomResponse(http.StatusBadRequest, "end_date must not be before start_date", nil)
+	}
+
+	existing, err := u.repo.GetById(user.ID, req.ID, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "recurring not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetById: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	err = u.checkCategory(user.ID, existing.Type, req.CategoryId, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "category not found", nil)
+	} else if err != nil {
+		u.log.Errorf("checkCategory: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	// a client that leaves currency out keeps the stored one
+	if req.Currency == "" {
+		req.Currency = existing.Currency
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	data := map[string]any{
+		"category_id": req.CategoryId,
+		"frequency":   req.Frequency,
+		"start_date":  req.StartDate,
+		"end_date":    nullableDate(req.EndDate),
+		"value":       encValue,
+		"currency":    req.Currency,
+		"notes":       req.Notes,
+	}
+
+	// a new schedule is generated again from its start date. The rows of the
+	// old schedule are kept as they are, generate skips the dates they cover.
+	if existing.Frequency != req.Frequency || existing.StartDate.Format(constant.DateFormat) != req.StartDate {
+		data["last_generated_date"] = nil
+	}
+
+	err = u.repo.Update(user.ID, req.ID, data, tx)
+	if err != nil {
+		u.log.Errorf("failed update recurring: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := u.generateById(user.ID, req.ID, db); err != nil {
+		u.log.Errorf("failed generate recurring %d: %s", req.ID, err.Error())
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) Delete(user *userModel.User, id uint) (resp common.Response) {
+	db := config.GetDatabase()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	err = u.repo.Delete(user.ID, id, tx)
+	if err != nil {
+		u.log.Errorf("failed delete recurring: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+// Generate materializes every due occurrence of every rule, including the
+// ones missed while the server was down
+func (u *usecase) Generate() {
+	db := config.GetDatabase()
+	today := truncateDate(time.Now())
+
+	periods, err := u.periodRepo.ListPeriod(db)
+	if err != nil {
+		u.log.Errorf("periodRepo.ListPeriod: %s", err.Error())
+		return
+	}
+
+	dayOfMonth := make(map[uint]uint8, len(periods))
+	for _, p := range periods {
+		dayOfMonth[p.UserId] = p.DayOfMonth
+	}
+
+	rules, err := u.repo.ListDue(today.Format(constant.DateFormat), db)
+	if err != nil {
+		u.log.Errorf("repo.ListDue: %s", err.Error())
+		return
+	}
+
+	for _, rule := range rules {
+		if err := u.generate(&rule, dayOfMonth[rule.UserId], today, db); err != nil {
+			u.log.Errorf("failed generate recurring %d: %s", rule.ID, err.Error())
+		}
+	}
+}
+
+func (u *usecase) generateById(userId, id uint, db *sqlx.DB) error {
+	rule, err := u.repo.GetById(userId, id, db)
+	if err != nil {
+		return fmt.Errorf("repo.GetById: %w", err)
+	}
+
+	userPeriod, err := u.periodRepo.GetPeriod(userId, db)
+	if err != nil {
+		return fmt.Errorf("periodRepo.GetPeriod: %w", err)
+	}
+
+	return u.generate(&rule, userPeriod.DayOfMonth, truncateDate(time.Now()), db)
+}
+
+// generate inserts the occurrences of the rule after its last generated date
+// up to today and moves the last generated date forward in the same
+// transaction, so no date is ever generated twice. Without a last generated
+// date the rule may have rows from an earlier schedule, their dates are
+// skipped.
+func (u *usecase) generate(rule *model.GetRecurring, dayOfMonth uint8, today time.Time, db *sqlx.DB) error {
+	from := rule.StartDate
+	if rule.LastGeneratedDate != nil {
+		from = rule.LastGeneratedDate.AddDate(0, 0, 1)
+	}
+
+	to := today
+	if rule.EndDate != nil && rule.EndDate.Before(to) {
+		to = *rule.EndDate
+	}
+
+	if from.After(to) {
+		return nil
+	}
+
+	decValue, err := libs.Decrypt(fmt.Sprintf("%d", rule.UserId), rule.Value)
+	if err != nil {
+		return fmt.Errorf("error decrypting value: %w", err)
+	}
+
+	value, err := libs.ParseMoney(decValue)
+	if err != nil {
+		return fmt.Errorf("error parsing string: %w", err)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	generated := make(map[string]struct{})
+	if rule.LastGeneratedDate == nil {
+		dates, err := u.repo.ListGeneratedDate(rule.ID, rule.Type, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, date := range dates {
+			generated[date.Format(constant.DateFormat)] = struct{}{}
+		}
+	}
+
+	user := &userModel.User{ID: rule.UserId}
+	for _, date := range occurrences(rule, dayOfMonth, from, to) {
+		if _, ok := generated[date.Format(constant.DateFormat)]; ok {
+			continue
+		}
+
+		switch rule.Type {
+		case model.TypeIncome:
+			err = u.incomeUsecase.AddTx(user, &incomeModel.AddRequest{
+				CategoryId:  rule.CategoryId,
+				Date:        date.Format(constant.DateFormat),
+				Value:       value,
+				Currency:    rule.Currency,
+				Notes:       rule.Notes,
+				RecurringId: &rule.ID,
+			}, tx)
+		case model.TypeExpense:
+			err = u.expenseUsecase.AddTx(user, &expenseModel.AddRequest{
+				CategoryId:  rule.CategoryId,
+				Date:        date.Format(constant.DateFormat),
+				Value:       value,
+				Currency:    rule.Currency,
+				Notes:       rule.Notes,
+				RecurringId: &rule.ID,
+			}, tx)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed insert %s: %w", rule.Type, err)
+		}
+	}
+
+	err = u.repo.UpdateLastGenerated(rule.ID, to.Format(constant.DateFormat), tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (u *usecase) checkCategory(userId uint, recurringType string, categoryId uint, db *sqlx.DB) (err error) {
+	if recurringType == model.TypeIncome {
+		_, err = u.incomeRepo.GetCategoryById(userId, categoryId, db)
+	} else {
+		_, err = u.expenseRepo.GetCategoryById(userId, categoryId, db)
+	}
+
+	return
+}
+
+func toRecurringData(userId uint, data *model.GetRecurring) (result model.RecurringData, err error) {
+	decValue, err := libs.Decrypt(fmt.Sprintf("%d", userId), data.Value)
+	if err != nil {
+		return result, fmt.Errorf("error decrypting value: %w", err)
+	}
+
+	value, err := libs.ParseMoney(decValue)
+	if err != nil {
+		return result, fmt.Errorf("error parsing string: %w", err)
+	}
+
+	return model.RecurringData{
+		ID:                data.ID,
+		Type:              data.Type,
+		CategoryId:        data.CategoryId,
+		Category:          data.Category,
+		Frequency:         data.Frequency,
+		StartDate:         data.StartDate,
+		EndDate:           data.EndDate,
+		Value:             value,
+		Currency:          data.Currency,
+		Notes:             data.Notes,
+		LastGeneratedDate: data.LastGeneratedDate,
+	}, nil
+}
+
+func nullableDate(date string) *string {
+	if date == "" {
+		return nil
+	}
+
+	return &date
+}
+
+func truncateDate(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -11,58 +11,38 @@ import (
 	"github.com/fazriegi/money_management-be/module/cashflow/expense"
 	"github.com/fazriegi/money_management-be/module/cashflow/income"
 	"github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/transfer"
+	"github.com/fazriegi/money_management-be/module/common"
 	"github.com/jmoiron/sqlx"
 	"golang.org/x/sync/errgroup"
 )
 
 type Repository interface {
 	List(req *model.ListRequest, db *sqlx.DB) (result []model.GetCashflow, total uint, err error)
+	Export(req *model.ListRequest, db *sqlx.DB) (*sqlx.Rows, error)
+	ListTransaction(req *model.ListFilter, transactionType string, db *sqlx.DB) (result []model.GetTransaction, err error)
 }
 
 type repository struct {
-	expenseRepo expense.Repository
-	incomeRepo  income.Repository
+	expenseRepo  expense.Repository
+	incomeRepo   income.Repository
+	transferRepo transfer.Repository
 }
 
-func NewRepository(expenseRepo expense.Repository, incomeRepo income.Repository) Repository {
+func NewRepository(expenseRepo expense.Repository, incomeRepo income.Repository, transferRepo transfer.Repository) Repository {
 	return &repository{
 		expenseRepo,
 		incomeRepo,
+		transferRepo,
 	}
 }
 
 func (r *repository) List(req *model.ListRequest, db *sqlx.DB) (result []model.GetCashflow, total uint, err error) {
-	dialect := libs.GetDialect()
-
-	if req.Sort == nil {
-		sort := "date desc"
-		req.Sort = &sort
-	}
-
-	listFilter := model.ListFilter{
-		UserId:    req.UserId,
-		StartDate: req.StartDate,
-		EndDate:   req.EndDate,
+	dataset, err := r.listQuery(req, db)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	expenseDataset := r.expenseRepo.CreateListQuery(&listFilter)
-	incomeDataset := r.incomeRepo.CreateListQuery(&listFilter)
-	unionDataset := expenseDataset.Union(incomeDataset)
-
-	dataset := dialect.
-		From(unionDataset.As("obj")).
-		Select(
-			goqu.I("id"),
-			goqu.I("category"),
-			goqu.I("date"),
-			goqu.I("value"),
-			goqu.I("user_id"),
-			goqu.I("type"),
-		)
-
-	if req.Category != "" {
-		dataset = dataset.Where(goqu.I("category").ILike("%" + req.Category + "%"))
-	}
 	result = make([]model.GetCashflow, 0)
 	g, _ := errgroup.WithContext(context.Background())
 
@@ -110,3 +90,146 @@ func (r *repository) List(req *model.ListRequest, db *sqlx.DB) (result []model.G
 
 	return
 }
+
+// Export runs the list query with notes and without paging, reading the rows
+// is left to the caller so large exports are never held in memory
+func (r *repository) Export(req *model.ListRequest, db *sqlx.DB) (*sqlx.Rows, error) {
+	dataset, err := r.listQuery(req, db)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset = dataset.SelectAppend(goqu.I("notes"))
+	dataset = libs.PaginationRequest(dataset, common.PaginationRequest{Sort: req.Sort})
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	row, err := db.Queryx(sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return row, nil
+}
+
+// ListTransaction returns the incomes and expenses matching the filter, or
+// only one of them when the type is given
+func (r *repository) ListTransaction(req *model.ListFilter, transactionType string, db *sqlx.DB) (result []model.GetTransaction, err error) {
+	dialect := libs.GetDialect()
+
+	var union *goqu.SelectDataset
+	switch transactionType {
+	case model.TypeIncome:
+		union = r.incomeRepo.CreateListQuery(req)
+	case model.TypeExpense:
+		union = r.expenseRepo.CreateListQuery(req)
+	default:
+		union = r.incomeRepo.CreateListQuery(req).UnionAll(r.expenseRepo.CreateListQuery(req))
+	}
+
+	dataset := dialect.
+		From(union.As("obj")).
+		Select(
+			goqu.I("id"),
+			goqu.I("type"),
+			goqu.I("category_id"),
+			goqu.I("category"),
+			goqu.I("date"),
+			goqu.I("value"),
+			goqu.I("currency"),
+			goqu.I("account_id"),
+			goqu.I("account"),
+			goqu.I("notes"),
+		).
+		Order(goqu.I("date").Asc(), goqu.I("id").Asc())
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	row, err := db.Queryx(sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer row.Close()
+
+	result = make([]model.GetTransaction, 0)
+	err = libs.ScanRowsIntoStructs(row, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan rows into structs: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) listQuery(req *model.ListRequest, db *sqlx.DB) (dataset *goqu.SelectDataset, err error) {
+	dialect := libs.GetDialect()
+
+	if req.Sort == nil {
+		sort := "date desc"
+		req.Sort = &sort
+	}
+
+	listFilter := model.ListFilter{
+		UserId:    req.UserId,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		AccountId: req.AccountId,
+		TagIds:    req.TagIds,
+	}
+
+	// a category only belongs to one type, so it is always paired with the type filter
+	if req.CategoryId != 0 {
+		var categoryIds []uint
+		if req.Type == model.TypeIncome {
+			categoryIds, err = r.incomeRepo.ListCategoryDescendant(req.UserId, req.CategoryId, db)
+		} else {
+			categoryIds, err = r.expenseRepo.ListCategoryDescendant(req.UserId, req.CategoryId, db)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to list category descendant: %w", err)
+		}
+
+		listFilter.CategoryIds = categoryIds
+	}
+
+	var unionDataset *goqu.SelectDataset
+	switch req.Type {
+	case model.TypeIncome:
+		unionDataset = r.incomeRepo.CreateListQuery(&listFilter)
+	case model.TypeExpense:
+		unionDataset = r.expenseRepo.CreateListQuery(&listFilter)
+	case model.TypeTransfer:
+		unionDataset = r.transferRepo.CreateListQuery(&listFilter)
+	default:
+		expenseDataset := r.expenseRepo.CreateListQuery(&listFilter)
+		incomeDataset := r.incomeRepo.CreateListQuery(&listFilter)
+		transferDataset := r.transferRepo.CreateListQuery(&listFilter)
+		unionDataset = expenseDataset.Union(incomeDataset).Union(transferDataset)
+	}
+
+	dataset = dialect.
+		From(unionDataset.As("obj")).
+		Select(
+			goqu.I("id"),
+			goqu.I("category"),
+			goqu.I("date"),
+			goqu.I("value"),
+			goqu.I("user_id"),
+			goqu.I("type"),
+			goqu.I("account_id"),
+			goqu.I("account"),
+			goqu.I("currency"),
+		)
+
+	if req.Category != "" {
+		dataset = dataset.Where(goqu.I("category").ILike("%" + req.Category + "%"))
+	}
+
+	return dataset, nil
+}
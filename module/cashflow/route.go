@@ -4,14 +4,25 @@ import (
 	"github.com/fazriegi/money_management-be/config"
 	"github.com/fazriegi/money_management-be/libs"
 	"github.com/fazriegi/money_management-be/middleware"
+	"github.com/fazriegi/money_management-be/module/cashflow/aggregate"
 	"github.com/fazriegi/money_management-be/module/cashflow/expense"
 	"github.com/fazriegi/money_management-be/module/cashflow/income"
+	"github.com/fazriegi/money_management-be/module/cashflow/recurring"
+	"github.com/fazriegi/money_management-be/module/cashflow/rule"
+	"github.com/fazriegi/money_management-be/module/cashflow/transfer"
+	"github.com/fazriegi/money_management-be/module/master/account"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	"github.com/fazriegi/money_management-be/module/master/tag"
 	"github.com/gofiber/fiber/v2"
 )
 
 func NewRoute(app *fiber.App, jwt *libs.JWT) {
 	expense.NewRoute(app, jwt)
 	income.NewRoute(app, jwt)
+	recurring.NewRoute(app, jwt)
+	transfer.NewRoute(app, jwt)
+	rule.NewRoute(app, jwt)
 	cashflowRoute(app, jwt)
 }
 
@@ -19,13 +30,23 @@ func cashflowRoute(app *fiber.App, jwt *libs.JWT) {
 	log := config.GetLogger()
 	expenseRepo := expense.NewRepository()
 	incomeRepo := income.NewRepository()
-	incomeUsecase := income.NewUsecase(log, incomeRepo)
-	expenseUsecase := expense.NewUsecase(log, expenseRepo)
+	transferRepo := transfer.NewRepository()
+	periodRepo := period.NewRepository()
+	accountRepo := account.NewRepository()
+	currencyRepo := currency.NewRepository()
+	aggregator := aggregate.NewAggregator(aggregate.NewRepository())
+	tagRepo := tag.NewRepository()
+	matcher := rule.NewMatcher(rule.NewRepository())
+	incomeUsecase := income.NewUsecase(log, incomeRepo, periodRepo, accountRepo, currencyRepo, tagRepo, aggregator, matcher)
+	expenseUsecase := expense.NewUsecase(log, expenseRepo, periodRepo, accountRepo, currencyRepo, tagRepo, aggregator, matcher)
 
-	repo := NewRepository(expenseRepo, incomeRepo)
-	usecase := NewUsecase(log, repo, incomeUsecase, expenseUsecase)
+	repo := NewRepository(expenseRepo, incomeRepo, transferRepo)
+	usecase := NewUsecase(log, repo, periodRepo, currencyRepo, aggregator, incomeUsecase, expenseUsecase)
 	controller := NewController(log, usecase)
 
 	route := app.Group("/cashflow")
 	route.Get("/", middleware.Authentication(jwt), controller.List)
+	route.Get("/export.csv", middleware.Authentication(jwt), controller.Export)
+	route.Get("/duplicates", middleware.Authentication(jwt), controller.Duplicates)
+	route.Post("/duplicates/resolve", middleware.Authentication(jwt), controller.ResolveDuplicates)
 }
@@ -0,0 +1,132 @@
+package rule
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/rule/model"
+	"github.com/jmoiron/sqlx"
+)
+
+// Matcher loads the categorization rules of a user so transactions created
+// without a category can be given one
+type Matcher interface {
+	Load(userId uint, db *sqlx.DB) (*Set, error)
+}
+
+type matcher struct {
+	repo Repository
+}
+
+func NewMatcher(repo Repository) Matcher {
+	return &matcher{repo}
+}
+
+// Set is the decrypted and compiled rules of a user in evaluation order
+type Set struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	ruleType   string
+	categoryId uint
+	category   string
+	contains   string
+	pattern    *regexp.Regexp
+	minValue   *libs.Money
+	maxValue   *libs.Money
+}
+
+// Load decrypts the value bounds and compiles the patterns of every rule
+func (m *matcher) Load(userId uint, db *sqlx.DB) (*Set, error) {
+	rules, err := m.repo.List(&model.ListRequest{UserId: userId}, db)
+	if err != nil {
+		return nil, fmt.Errorf("repo.List: %w", err)
+	}
+
+	key := fmt.Sprintf("%d", userId)
+
+	result := &Set{rules: make([]compiledRule, 0, len(rules))}
+	for _, rule := range rules {
+		compiled := compiledRule{
+			ruleType:   rule.Type,
+			categoryId: rule.CategoryId,
+			category:   rule.Category,
+		}
+
+		if rule.NotesContains != nil {
+			compiled.contains = strings.ToLower(*rule.NotesContains)
+		}
+
+		if rule.NotesPattern != nil {
+			compiled.pattern, err = regexp.Compile(*rule.NotesPattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern of rule %d: %w", rule.ID, err)
+			}
+		}
+
+		compiled.minValue, err = decryptBound(key, rule.MinValue)
+		if err != nil {
+			return nil, err
+		}
+
+		compiled.maxValue, err = decryptBound(key, rule.MaxValue)
+		if err != nil {
+			return nil, err
+		}
+
+		result.rules = append(result.rules, compiled)
+	}
+
+	return result, nil
+}
+
+// Match returns the category of the first rule of the type matching the
+// value and notes
+func (s *Set) Match(ruleType string, value libs.Money, notes string) (categoryId uint, category string, ok bool) {
+	for _, rule := range s.rules {
+		if rule.ruleType != ruleType {
+			continue
+		}
+
+		if rule.contains != "" && !strings.Contains(strings.ToLower(notes), rule.contains) {
+			continue
+		}
+
+		if rule.pattern != nil && !rule.pattern.MatchString(notes) {
+			continue
+		}
+
+		if rule.minValue != nil && value.Cmp(*rule.minValue) < 0 {
+			continue
+		}
+
+		if rule.maxValue != nil && value.Cmp(*rule.maxValue) > 0 {
+			continue
+		}
+
+		return rule.categoryId, rule.category, true
+	}
+
+	return 0, "", false
+}
+
+func decryptBound(key string, value *string) (*libs.Money, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	decValue, err := libs.Decrypt(key, *value)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting value: %w", err)
+	}
+
+	bound, err := libs.ParseMoney(decValue)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing string: %w", err)
+	}
+
+	return &bound, nil
+}
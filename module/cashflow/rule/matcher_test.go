@@ -0,0 +1,149 @@
+package rule
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/libs"
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/rule/model"
+	"github.com/jmoiron/sqlx"
+)
+
+const testUserId = 7
+
+// useLegacyKeys loads a config that encrypts with the legacy key format, which
+// needs no data key from the database
+func useLegacyKeys(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	content := `{"secret": {"encryptionKey": "test-key", "dataKeyVersion": 0}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(dir)
+	config.NewViper()
+}
+
+// fakeRepository lists fixed rules, already in evaluation order
+type fakeRepository struct {
+	Repository
+	rules []model.GetRule
+}
+
+func (r *fakeRepository) List(req *model.ListRequest, db *sqlx.DB) ([]model.GetRule, error) {
+	return r.rules, nil
+}
+
+type testRule struct {
+	ruleType   string
+	categoryId uint
+	contains   string
+	pattern    string
+	minValue   string
+	maxValue   string
+}
+
+func loadRules(t *testing.T, rules ...testRule) *Set {
+	t.Helper()
+
+	key := fmt.Sprintf("%d", testUserId)
+	bound := func(value string) *string {
+		if value == "" {
+			return nil
+		}
+
+		money := libs.MustParseMoney(value)
+		encValue, err := encryptBound(key, &money)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return encValue
+	}
+
+	listData := make([]model.GetRule, len(rules))
+	for i, rule := range rules {
+		listData[i] = model.GetRule{
+			ID:            uint(i + 1),
+			Type:          rule.ruleType,
+			CategoryId:    rule.categoryId,
+			Category:      "category",
+			NotesContains: nullableString(rule.contains),
+			NotesPattern:  nullableString(rule.pattern),
+			MinValue:      bound(rule.minValue),
+			MaxValue:      bound(rule.maxValue),
+			UserId:        testUserId,
+		}
+	}
+
+	set, err := NewMatcher(&fakeRepository{rules: listData}).Load(testUserId, nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	return set
+}
+
+func TestMatch(t *testing.T) {
+	useLegacyKeys(t)
+
+	set := loadRules(t,
+		testRule{ruleType: cashflowModel.TypeExpense, categoryId: 1, contains: "coffee", maxValue: "50"},
+		testRule{ruleType: cashflowModel.TypeExpense, categoryId: 2, contains: "coffee"},
+		testRule{ruleType: cashflowModel.TypeExpense, categoryId: 3, pattern: `^(?i)pln\b`},
+		testRule{ruleType: cashflowModel.TypeExpense, categoryId: 4, minValue: "1000", maxValue: "2000"},
+		testRule{ruleType: cashflowModel.TypeIncome, categoryId: 5, contains: "salary"},
+	)
+
+	tests := []struct {
+		name      string
+		ruleType  string
+		value     string
+		notes     string
+		wantId    uint
+		wantMatch bool
+	}{
+		{"first rule in priority order wins", cashflowModel.TypeExpense, "20", "Coffee Shop", 1, true},
+		{"falls through to the next rule", cashflowModel.TypeExpense, "75", "Coffee Shop", 2, true},
+		{"max value is inclusive", cashflowModel.TypeExpense, "50", "coffee", 1, true},
+		{"contains ignores case", cashflowModel.TypeExpense, "20", "STARBUCKS COFFEE", 1, true},
+		{"pattern", cashflowModel.TypeExpense, "300", "PLN prepaid", 3, true},
+		{"pattern is anchored", cashflowModel.TypeExpense, "300", "paid PLN", 0, false},
+		{"min value is inclusive", cashflowModel.TypeExpense, "1000", "rent", 4, true},
+		{"max value of a range is inclusive", cashflowModel.TypeExpense, "2000", "rent", 4, true},
+		{"below the min value", cashflowModel.TypeExpense, "999.99", "rent", 0, false},
+		{"above the max value", cashflowModel.TypeExpense, "2000.01", "rent", 0, false},
+		{"rules of the other type are skipped", cashflowModel.TypeIncome, "20", "coffee", 0, false},
+		{"income rule", cashflowModel.TypeIncome, "5000", "Monthly salary", 5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			categoryId, _, ok := set.Match(tt.ruleType, libs.MustParseMoney(tt.value), tt.notes)
+			if ok != tt.wantMatch || categoryId != tt.wantId {
+				t.Errorf("Match(%s, %s, %q) = %d, %v, want %d, %v", tt.ruleType, tt.value, tt.notes, categoryId, ok, tt.wantId, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestLoadInvalidPattern(t *testing.T) {
+	useLegacyKeys(t)
+
+	repo := &fakeRepository{rules: []model.GetRule{{
+		ID:           1,
+		Type:         cashflowModel.TypeExpense,
+		CategoryId:   1,
+		NotesPattern: nullableString("("),
+	}}}
+
+	if _, err := NewMatcher(repo).Load(testUserId, nil); err == nil {
+		t.Error("Load() error = nil, want an invalid pattern error")
+	}
+}
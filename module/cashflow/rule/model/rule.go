@@ -0,0 +1,86 @@
+package model
+
+import "github.com/fazriegi/money_management-be/libs"
+
+// Rule picks the category of a transaction. Every condition that is set must
+// match, rules are evaluated by ascending priority and the first match wins.
+type Rule struct {
+	ID            uint    `db:"id"`
+	Type          string  `db:"type"`
+	CategoryId    uint    `db:"category_id"`
+	Priority      int     `db:"priority"`
+	NotesContains *string `db:"notes_contains"`
+	NotesPattern  *string `db:"notes_pattern"`
+	MinValue      *string `db:"min_value"`
+	MaxValue      *string `db:"max_value"`
+	UserId        uint    `db:"user_id"`
+}
+
+// GetRule takes its category from the income or expense categories by its
+// type. A category is never deleted while a rule still uses it.
+type GetRule struct {
+	ID            uint    `db:"id"`
+	Type          string  `db:"type"`
+	CategoryId    uint    `db:"category_id"`
+	Category      string  `db:"category"`
+	Priority      int     `db:"priority"`
+	NotesContains *string `db:"notes_contains"`
+	NotesPattern  *string `db:"notes_pattern"`
+	MinValue      *string `db:"min_value"`
+	MaxValue      *string `db:"max_value"`
+	UserId        uint    `db:"user_id"`
+}
+
+type RuleData struct {
+	ID            uint        `json:"id"`
+	Type          string      `json:"type"`
+	CategoryId    uint        `json:"category_id"`
+	Category      string      `json:"category"`
+	Priority      int         `json:"priority"`
+	NotesContains string      `json:"notes_contains"`
+	NotesPattern  string      `json:"notes_pattern"`
+	MinValue      *libs.Money `json:"min_value"`
+	MaxValue      *libs.Money `json:"max_value"`
+}
+
+// AddRequest conditions: NotesContains matches notes ignoring case,
+// NotesPattern is a regular expression and the value bounds are inclusive
+type AddRequest struct {
+	Type          string      `json:"type" validate:"required,oneof=income expense"`
+	CategoryId    uint        `json:"category_id" validate:"required"`
+	Priority      int         `json:"priority" validate:"min=0"`
+	NotesContains string      `json:"notes_contains" validate:"max=255"`
+	NotesPattern  string      `json:"notes_pattern" validate:"max=255"`
+	MinValue      *libs.Money `json:"min_value"`
+	MaxValue      *libs.Money `json:"max_value"`
+}
+
+type ListRequest struct {
+	Type   string `query:"type" validate:"omitempty,oneof=income expense"`
+	UserId uint
+}
+
+type UpdateRequest struct {
+	ID            uint
+	CategoryId    uint        `json:"category_id" validate:"required"`
+	Priority      int         `json:"priority" validate:"min=0"`
+	NotesContains string      `json:"notes_contains" validate:"max=255"`
+	NotesPattern  string      `json:"notes_pattern" validate:"max=255"`
+	MinValue      *libs.Money `json:"min_value"`
+	MaxValue      *libs.Money `json:"max_value"`
+}
+
+// ApplyRequest re-categorizes the transactions dated within the range, of
+// both types when Type is empty
+type ApplyRequest struct {
+	Type      string `json:"type" validate:"omitempty,oneof=income expense"`
+	StartDate string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" validate:"required,datetime=2006-01-02"`
+}
+
+type Transaction struct {
+	ID         uint   `db:"id"`
+	CategoryId uint   `db:"category_id"`
+	Value      string `db:"value"`
+	Notes      string `db:"notes"`
+}
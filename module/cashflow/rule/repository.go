@@ -0,0 +1,260 @@
+package rule
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/fazriegi/money_management-be/libs"
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/rule/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Insert(data *model.Rule, tx *sqlx.Tx) error
+	List(req *model.ListRequest, db *sqlx.DB) (result []model.GetRule, err error)
+	GetById(userId, id uint, db *sqlx.DB) (result model.GetRule, err error)
+	Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error
+	Delete(userId, id uint, tx *sqlx.Tx) error
+	GetCategory(userId uint, ruleType string, categoryId uint, db *sqlx.DB) (name string, err error)
+	ListTransactionForUpdate(userId uint, ruleType, startDate, endDate string, tx *sqlx.Tx) (result []model.Transaction, err error)
+	UpdateTransactionCategory(userId uint, ruleType string, ids []uint, categoryId uint, tx *sqlx.Tx) error
+}
+
+type repository struct{}
+
+func NewRepository() Repository {
+	return &repository{}
+}
+
+func (r *repository) Insert(data *model.Rule, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("category_rule").Rows(*data)
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the rules in the order they are evaluated
+func (r *repository) List(req *model.ListRequest, db *sqlx.DB) (result []model.GetRule, err error) {
+	dataset := selectQuery().
+		Where(goqu.I("r.user_id").Eq(req.UserId)).
+		Order(goqu.I("r.priority").Asc(), goqu.I("r.id").Asc())
+
+	if req.Type != "" {
+		dataset = dataset.Where(goqu.I("r.type").Eq(req.Type))
+	}
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.GetRule, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) GetById(userId, id uint, db *sqlx.DB) (result model.GetRule, err error) {
+	dataset := selectQuery().
+		Where(
+			goqu.I("r.user_id").Eq(userId),
+			goqu.I("r.id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("category_rule").
+		Set(data).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(userId, id uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Delete("category_rule").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetCategory(userId uint, ruleType string, categoryId uint, db *sqlx.DB) (name string, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From(categoryTable(ruleType)).
+		Select(goqu.I("name")).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(categoryId),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return name, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&name, sql, val...)
+	if err != nil {
+		return name, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+// ListTransactionForUpdate returns the incomes or expenses dated within the
+// range and locks them until the transaction ends
+func (r *repository) ListTransactionForUpdate(userId uint, ruleType, startDate, endDate string, tx *sqlx.Tx) (result []model.Transaction, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From(transactionTable(ruleType)).
+		Select(
+			goqu.I("id"),
+			goqu.I("category_id"),
+			goqu.I("value"),
+			goqu.COALESCE(goqu.I("notes"), "").As("notes"),
+		).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("date").Between(goqu.Range(startDate, endDate)),
+		).
+		Order(goqu.I("id").Asc()).
+		ForUpdate(exp.Wait)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.Transaction, 0)
+	err = tx.Select(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) UpdateTransactionCategory(userId uint, ruleType string, ids []uint, categoryId uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update(transactionTable(ruleType)).
+		Set(goqu.Record{"category_id": categoryId}).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").In(ids),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+// selectQuery joins a rule with the category table matching its type
+func selectQuery() *goqu.SelectDataset {
+	dialect := libs.GetDialect()
+
+	return dialect.
+		From(goqu.T("category_rule").As("r")).
+		LeftJoin(goqu.T("user_income_category").As("uic"), goqu.On(
+			goqu.I("uic.id").Eq(goqu.I("r.category_id")),
+			goqu.I("uic.user_id").Eq(goqu.I("r.user_id")),
+			goqu.I("r.type").Eq(cashflowModel.TypeIncome),
+		)).
+		LeftJoin(goqu.T("user_expense_category").As("uec"), goqu.On(
+			goqu.I("uec.id").Eq(goqu.I("r.category_id")),
+			goqu.I("uec.user_id").Eq(goqu.I("r.user_id")),
+			goqu.I("r.type").Eq(cashflowModel.TypeExpense),
+		)).
+		Select(
+			goqu.I("r.id"),
+			goqu.I("r.type"),
+			goqu.I("r.category_id"),
+			goqu.COALESCE(goqu.I("uic.name"), goqu.I("uec.name")).As("category"),
+			goqu.I("r.priority"),
+			goqu.I("r.notes_contains"),
+			goqu.I("r.notes_pattern"),
+			goqu.I("r.min_value"),
+			goqu.I("r.max_value"),
+			goqu.I("r.user_id"),
+		)
+}
+
+func transactionTable(ruleType string) string {
+	if ruleType == cashflowModel.TypeIncome {
+		return "income"
+	}
+
+	return "expense"
+}
+
+func categoryTable(ruleType string) string {
+	if ruleType == cashflowModel.TypeIncome {
+		return "user_income_category"
+	}
+
+	return "user_expense_category"
+}
@@ -0,0 +1,25 @@
+package rule
+
+import (
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/middleware"
+	"github.com/fazriegi/money_management-be/module/cashflow/aggregate"
+	"github.com/gofiber/fiber/v2"
+)
+
+func NewRoute(app *fiber.App, jwt *libs.JWT) {
+	log := config.GetLogger()
+	repo := NewRepository()
+	matcher := NewMatcher(repo)
+	aggregator := aggregate.NewAggregator(aggregate.NewRepository())
+	usecase := NewUsecase(log, repo, matcher, aggregator)
+	controller := NewController(log, usecase)
+
+	route := app.Group("/rule")
+	route.Post("/", middleware.Authentication(jwt), controller.Add)
+	route.Get("/", middleware.Authentication(jwt), controller.List)
+	route.Post("/apply", middleware.Authentication(jwt), controller.Apply)
+	route.Put("/:id", middleware.Authentication(jwt), controller.Update)
+	route.Delete("/:id", middleware.Authentication(jwt), controller.Delete)
+}
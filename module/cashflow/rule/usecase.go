@@ -0,0 +1,375 @@
+package rule
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/aggregate"
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/rule/model"
+	"github.com/fazriegi/money_management-be/module/common"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/sirupsen/logrus"
+)
+
+type Usecase interface {
+	Add(user *userModel.User, req *model.AddRequest) (resp common.Response)
+	List(user *userModel.User, req *model.ListRequest) (resp common.Response)
+	Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response)
+	Delete(user *userModel.User, id uint) (resp common.Response)
+	Apply(user *userModel.User, req *model.ApplyRequest) (resp common.Response)
+}
+
+type usecase struct {
+	log        *logrus.Logger
+	repo       Repository
+	matcher    Matcher
+	aggregator aggregate.Aggregator
+}
+
+func NewUsecase(log *logrus.Logger, repo Repository, matcher Matcher, aggregator aggregate.Aggregator) Usecase {
+	return &usecase{
+		log,
+		repo,
+		matcher,
+		aggregator,
+	}
+}
+
+func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	if message := checkCondition(req.NotesContains, req.NotesPattern, req.MinValue, req.MaxValue); message != "" {
+		return resp.CustomResponse(http.StatusBadRequest, message, nil)
+	}
+
+	_, err := u.repo.GetCategory(user.ID, req.Type, req.CategoryId, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "category not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetCategory: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	key := fmt.Sprintf("%d", user.ID)
+
+	minValue, err := encryptBound(key, req.MinValue)
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	maxValue, err := encryptBound(key, req.MaxValue)
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	data := model.Rule{
+		Type:          req.Type,
+		CategoryId:    req.CategoryId,
+		Priority:      req.Priority,
+		NotesContains: nullableString(req.NotesContains),
+		NotesPattern:  nullableString(req.NotesPattern),
+		MinValue:      minValue,
+		MaxValue:      maxValue,
+		UserId:        user.ID,
+	}
+
+	err = u.repo.Insert(&data, tx)
+	if err != nil {
+		u.log.Errorf("failed insert rule: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", nil)
+}
+
+func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	req.UserId = user.ID
+	listData, err := u.repo.List(req, db)
+	if err != nil {
+		u.log.Errorf("repo.List: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	key := fmt.Sprintf("%d", user.ID)
+
+	result := make([]model.RuleData, len(listData))
+	for i, data := range listData {
+		result[i] = model.RuleData{
+			ID:         data.ID,
+			Type:       data.Type,
+			CategoryId: data.CategoryId,
+			Category:   data.Category,
+			Priority:   data.Priority,
+		}
+
+		if data.NotesContains != nil {
+			result[i].NotesContains = *data.NotesContains
+		}
+
+		if data.NotesPattern != nil {
+			result[i].NotesPattern = *data.NotesPattern
+		}
+
+		result[i].MinValue, err = decryptBound(key, data.MinValue)
+		if err != nil {
+			u.log.Errorf("decryptBound: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		result[i].MaxValue, err = decryptBound(key, data.MaxValue)
+		if err != nil {
+			u.log.Errorf("decryptBound: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	if message := checkCondition(req.NotesContains, req.NotesPattern, req.MinValue, req.MaxValue); message != "" {
+		return resp.CustomResponse(http.StatusBadRequest, message, nil)
+	}
+
+	existing, err := u.repo.GetById(user.ID, req.ID, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "rule not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetById: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	_, err = u.repo.GetCategory(user.ID, existing.Type, req.CategoryId, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "category not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetCategory: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	key := fmt.Sprintf("%d", user.ID)
+
+	minValue, err := encryptBound(key, req.MinValue)
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	maxValue, err := encryptBound(key, req.MaxValue)
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	data := map[string]any{
+		"category_id":    req.CategoryId,
+		"priority":       req.Priority,
+		"notes_contains": nullableString(req.NotesContains),
+		"notes_pattern":  nullableString(req.NotesPattern),
+		"min_value":      minValue,
+		"max_value":      maxValue,
+	}
+
+	err = u.repo.Update(user.ID, req.ID, data, tx)
+	if err != nil {
+		u.log.Errorf("failed update rule: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) Delete(user *userModel.User, id uint) (resp common.Response) {
+	db := config.GetDatabase()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	err = u.repo.Delete(user.ID, id, tx)
+	if err != nil {
+		u.log.Errorf("failed delete rule: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+// Apply runs the rules again over the transactions dated within the range and
+// moves every matching transaction into the category of its first matching
+// rule. Transactions no rule matches keep their category.
+func (u *usecase) Apply(user *userModel.User, req *model.ApplyRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	key := fmt.Sprintf("%d", user.ID)
+
+	if req.EndDate < req.StartDate {
+		return resp.CustomResponse(http.StatusBadRequest, "end_date must not be before start_date", nil)
+	}
+
+	rules, err := u.matcher.Load(user.ID, db)
+	if err != nil {
+		u.log.Errorf("matcher.Load: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	// the transactions are listed and locked inside tx, so none changes
+	// between matching and updating it
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	ruleTypes := []string{cashflowModel.TypeIncome, cashflowModel.TypeExpense}
+	if req.Type != "" {
+		ruleTypes = []string{req.Type}
+	}
+
+	// matched transaction ids keyed by type and then by their new category
+	changes := make(map[string]map[uint][]uint, len(ruleTypes))
+	var updated int
+	for _, ruleType := range ruleTypes {
+		transactions, err := u.repo.ListTransactionForUpdate(user.ID, ruleType, req.StartDate, req.EndDate, tx)
+		if err != nil {
+			u.log.Errorf("repo.ListTransactionForUpdate: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		changes[ruleType] = make(map[uint][]uint)
+		for _, transaction := range transactions {
+			decValue, err := libs.Decrypt(key, transaction.Value)
+			if err != nil {
+				u.log.Errorf("error decrypting value: %s", err.Error())
+				return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+			}
+
+			value, err := libs.ParseMoney(decValue)
+			if err != nil {
+				u.log.Errorf("error parsing string: %s", err.Error())
+				return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+			}
+
+			categoryId, _, ok := rules.Match(ruleType, value, transaction.Notes)
+			if !ok || categoryId == transaction.CategoryId {
+				continue
+			}
+
+			changes[ruleType][categoryId] = append(changes[ruleType][categoryId], transaction.ID)
+			updated++
+		}
+	}
+
+	if updated == 0 {
+		return resp.CustomResponse(http.StatusOK, "success", map[string]any{"updated": 0})
+	}
+
+	for ruleType, categories := range changes {
+		for categoryId, ids := range categories {
+			err = u.repo.UpdateTransactionCategory(user.ID, ruleType, ids, categoryId, tx)
+			if err != nil {
+				u.log.Errorf("failed update %s category: %s", ruleType, err.Error())
+				return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+			}
+		}
+	}
+
+	err = u.aggregator.Invalidate(user.ID, tx)
+	if err != nil {
+		u.log.Errorf("aggregator.Invalidate: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", map[string]any{"updated": updated})
+}
+
+// checkCondition returns why the conditions of a rule are invalid, or an
+// empty string when they are valid
+func checkCondition(notesContains, notesPattern string, minValue, maxValue *libs.Money) string {
+	if notesContains == "" && notesPattern == "" && minValue == nil && maxValue == nil {
+		return "rule needs at least one condition"
+	}
+
+	if notesPattern != "" {
+		if _, err := regexp.Compile(notesPattern); err != nil {
+			return "notes_pattern is not a valid regular expression"
+		}
+	}
+
+	if minValue != nil && maxValue != nil && minValue.Cmp(*maxValue) > 0 {
+		return "min_value must not be greater than max_value"
+	}
+
+	return ""
+}
+
+func encryptBound(key string, value *libs.Money) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	encValue, err := libs.Encrypt(key, value.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &encValue, nil
+}
+
+func nullableString(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/common"
+)
+
+type Transfer struct {
+	ID            uint        `db:"id"`
+	FromAccountId uint        `db:"from_account_id"`
+	ToAccountId   uint        `db:"to_account_id"`
+	Date          interface{} `db:"date"`
+	Value         string      `db:"value"`
+	Notes         string      `db:"notes"`
+	UserId        uint        `db:"user_id"`
+}
+
+type GetTransfer struct {
+	ID            uint        `db:"id"`
+	FromAccountId uint        `db:"from_account_id"`
+	FromAccount   string      `db:"from_account"`
+	ToAccountId   uint        `db:"to_account_id"`
+	ToAccount     string      `db:"to_account"`
+	Date          interface{} `db:"date"`
+	Value         string      `db:"value"`
+	Notes         string      `db:"notes"`
+	UserId        uint        `db:"user_id"`
+}
+
+type TransferData struct {
+	ID            uint        `json:"id"`
+	FromAccountId uint        `json:"from_account_id"`
+	FromAccount   string      `json:"from_account"`
+	ToAccountId   uint        `json:"to_account_id"`
+	ToAccount     string      `json:"to_account"`
+	Date          interface{} `json:"date"`
+	Value         libs.Money  `json:"value"`
+	Notes         string      `json:"notes"`
+}
+
+type AddRequest struct {
+	FromAccountId uint        `json:"from_account_id" validate:"required"`
+	ToAccountId   uint        `json:"to_account_id" validate:"required,nefield=FromAccountId"`
+	Date          interface{} `json:"date" validate:"required"`
+	Value         libs.Money  `json:"value" validate:"required,gt=0"`
+	Notes         string      `json:"notes"`
+}
+
+type ListRequest struct {
+	common.PaginationRequest
+	AccountId uint   `query:"account_id"`
+	StartDate string `query:"start_date"`
+	EndDate   string `query:"end_date"`
+	Period    string `query:"period"`
+	UserId    uint
+}
+
+type UpdateRequest struct {
+	ID            uint
+	FromAccountId uint        `json:"from_account_id" validate:"required"`
+	ToAccountId   uint        `json:"to_account_id" validate:"required,nefield=FromAccountId"`
+	Date          interface{} `json:"date" validate:"required"`
+	Value         libs.Money  `json:"value" validate:"required,gt=0"`
+	Notes         string      `json:"notes"`
+}
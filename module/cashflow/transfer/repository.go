@@ -0,0 +1,240 @@
+package transfer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/fazriegi/money_management-be/libs"
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/transfer/model"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/sync/errgroup"
+)
+
+type Repository interface {
+	Insert(data *model.Transfer, tx *sqlx.Tx) error
+	List(req *model.ListRequest, db *sqlx.DB) (result []model.GetTransfer, total uint, err error)
+	Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error
+	Delete(userId, id uint, tx *sqlx.Tx) error
+	CreateListQuery(req *cashflowModel.ListFilter) *goqu.SelectDataset
+}
+
+type repository struct{}
+
+func NewRepository() Repository {
+	return &repository{}
+}
+
+func (r *repository) Insert(data *model.Transfer, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("transfer").Rows(*data)
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) List(req *model.ListRequest, db *sqlx.DB) (result []model.GetTransfer, total uint, err error) {
+	if req.Sort == nil {
+		sort := "date desc"
+		req.Sort = &sort
+	}
+
+	dialect := libs.GetDialect()
+
+	dataset := dialect.
+		From("transfer").
+		Join(goqu.T("account").As("fa"), goqu.On(goqu.I("fa.id").Eq(goqu.I("transfer.from_account_id")))).
+		Join(goqu.T("account").As("ta"), goqu.On(goqu.I("ta.id").Eq(goqu.I("transfer.to_account_id")))).
+		Select(
+			goqu.I("transfer.id"),
+			goqu.I("transfer.from_account_id"),
+			goqu.I("fa.name").As("from_account"),
+			goqu.I("transfer.to_account_id"),
+			goqu.I("ta.name").As("to_account"),
+			goqu.I("transfer.date"),
+			goqu.I("transfer.value"),
+			goqu.COALESCE(goqu.I("transfer.notes"), "").As("notes"),
+			goqu.I("transfer.user_id"),
+		).
+		Where(
+			goqu.I("transfer.user_id").Eq(req.UserId),
+		)
+
+	if req.StartDate != "" && req.EndDate != "" {
+		dataset = dataset.Where(goqu.I("transfer.date").Between(exp.NewRangeVal(req.StartDate, req.EndDate)))
+	}
+
+	if req.AccountId != 0 {
+		dataset = dataset.Where(goqu.Or(
+			goqu.I("transfer.from_account_id").Eq(req.AccountId),
+			goqu.I("transfer.to_account_id").Eq(req.AccountId),
+		))
+	}
+
+	result = make([]model.GetTransfer, 0)
+	g, _ := errgroup.WithContext(context.Background())
+
+	g.Go(func() error {
+		countDataset := dataset.Select(goqu.COUNT("*").As("total"))
+
+		countSQL, countVals, err := countDataset.ToSQL()
+		if err != nil {
+			return fmt.Errorf("failed to build count SQL: %w", err)
+		}
+
+		if err := db.Get(&total, countSQL, countVals...); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to query count: %w", err)
+		}
+
+		return nil
+	})
+
+	g.Go(func() error {
+		dataset := libs.PaginationRequest(dataset, req.PaginationRequest)
+
+		sql, val, err := dataset.ToSQL()
+		if err != nil {
+			return fmt.Errorf("failed to build SQL query: %w", err)
+		}
+
+		row, err := db.Queryx(sql, val...)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+		defer row.Close()
+
+		err = libs.ScanRowsIntoStructs(row, &result)
+		if err != nil {
+			return fmt.Errorf("failed to scan rows into structs: %w", err)
+		}
+
+		return nil
+	})
+
+	err = g.Wait()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return
+}
+
+func (r *repository) Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	selectQ, selectV, err := dialect.From("transfer").
+		Where(
+			goqu.I("id").Eq(id),
+			goqu.I("user_id").Eq(userId),
+		).
+		ForUpdate(exp.Wait).
+		ToSQL()
+
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(selectQ, selectV...)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	dataset := dialect.Update("transfer").Set(data).
+		Where(
+			goqu.I("id").Eq(id),
+			goqu.I("user_id").Eq(userId),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(userId, id uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Delete("transfer").
+		Where(
+			goqu.I("id").Eq(id),
+			goqu.I("user_id").Eq(userId),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	return nil
+}
+
+// CreateListQuery shapes transfers like the income and expense list queries
+// so they can be merged into the cashflow listing. A transfer has no
+// category, the accounts it moves money between are shown instead.
+func (r *repository) CreateListQuery(req *cashflowModel.ListFilter) *goqu.SelectDataset {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.
+		From("transfer").
+		Join(goqu.T("account").As("fa"), goqu.On(goqu.I("fa.id").Eq(goqu.I("transfer.from_account_id")))).
+		Join(goqu.T("account").As("ta"), goqu.On(goqu.I("ta.id").Eq(goqu.I("transfer.to_account_id")))).
+		Select(
+			goqu.I("transfer.id"),
+			goqu.L("NULL").As("category_id"),
+			goqu.Func("CONCAT", goqu.I("fa.name"), " -> ", goqu.I("ta.name")).As("category"),
+			goqu.I("transfer.date"),
+			goqu.I("transfer.value"),
+			goqu.I("transfer.user_id"),
+			goqu.V(cashflowModel.TypeTransfer).As("type"),
+			goqu.I("transfer.from_account_id").As("account_id"),
+			goqu.I("fa.name").As("account"),
+			goqu.L("NULL").As("currency"),
+			goqu.COALESCE(goqu.I("transfer.notes"), "").As("notes"),
+		).
+		Where(
+			goqu.I("transfer.user_id").Eq(req.UserId),
+		)
+
+	if req.StartDate != "" && req.EndDate != "" {
+		dataset = dataset.Where(goqu.I("transfer.date").Between(exp.NewRangeVal(req.StartDate, req.EndDate)))
+	}
+
+	if req.AccountId != 0 {
+		dataset = dataset.Where(goqu.Or(
+			goqu.I("transfer.from_account_id").Eq(req.AccountId),
+			goqu.I("transfer.to_account_id").Eq(req.AccountId),
+		))
+	}
+
+	// transfers have no category nor tags, so filtering by one leaves none of them
+	if len(req.CategoryIds) > 0 || len(req.TagIds) > 0 {
+		dataset = dataset.Where(goqu.L("FALSE"))
+	}
+
+	return dataset
+}
@@ -0,0 +1,228 @@
+package transfer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/transfer/model"
+	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/account"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type Usecase interface {
+	Add(user *userModel.User, req *model.AddRequest) (resp common.Response)
+	List(user *userModel.User, req *model.ListRequest) (resp common.Response)
+	Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response)
+	Delete(user *userModel.User, id uint) (resp common.Response)
+}
+
+type usecase struct {
+	log         *logrus.Logger
+	repo        Repository
+	accountRepo account.Repository
+	periodRepo  period.Repository
+}
+
+func NewUsecase(log *logrus.Logger, repo Repository, accountRepo account.Repository, periodRepo period.Repository) Usecase {
+	return &usecase{
+		log,
+		repo,
+		accountRepo,
+		periodRepo,
+	}
+}
+
+func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	err := u.checkAccount(user.ID, db, req.FromAccountId, req.ToAccountId)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "account not found", nil)
+	} else if err != nil {
+		u.log.Errorf("checkAccount: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	// a single row holds both sides, the source account is debited and the
+	// destination account credited by the same value
+	data := model.Transfer{
+		FromAccountId: req.FromAccountId,
+		ToAccountId:   req.ToAccountId,
+		Date:          req.Date,
+		Value:         encValue,
+		Notes:         req.Notes,
+		UserId:        user.ID,
+	}
+
+	err = u.repo.Insert(&data, tx)
+	if err != nil {
+		u.log.Errorf("failed insert transfer: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", nil)
+}
+
+func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	if req.Period != "" {
+		startDate, endDate, err := period.ResolveDates(u.periodRepo, user.ID, req.Period, db)
+		if err != nil && errors.Is(err, period.ErrInvalidPeriod) {
+			return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+		} else if err != nil {
+			u.log.Errorf("period.ResolveDates: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		req.StartDate = startDate
+		req.EndDate = endDate
+	}
+
+	req.UserId = user.ID
+	listData, total, err := u.repo.List(req, db)
+	if err != nil {
+		u.log.Errorf("repo.List: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	result := make([]model.TransferData, len(listData))
+	for i, data := range listData {
+		decValue, err := libs.Decrypt(fmt.Sprintf("%d", user.ID), data.Value)
+		if err != nil {
+			u.log.Errorf("error decrypting value: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		value, err := libs.ParseMoney(decValue)
+		if err != nil {
+			u.log.Errorf("error parsing string: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		result[i] = model.TransferData{
+			ID:            data.ID,
+			FromAccountId: data.FromAccountId,
+			FromAccount:   data.FromAccount,
+			ToAccountId:   data.ToAccountId,
+			ToAccount:     data.ToAccount,
+			Date:          data.Date,
+			Value:         value,
+			Notes:         data.Notes,
+		}
+	}
+
+	responseData := map[string]any{
+		"data":  result,
+		"total": total,
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", responseData)
+}
+
+func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	err := u.checkAccount(user.ID, db, req.FromAccountId, req.ToAccountId)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "account not found", nil)
+	} else if err != nil {
+		u.log.Errorf("checkAccount: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.Value.String())
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	data := map[string]any{
+		"from_account_id": req.FromAccountId,
+		"to_account_id":   req.ToAccountId,
+		"date":            req.Date,
+		"value":           encValue,
+		"notes":           req.Notes,
+	}
+
+	err = u.repo.Update(user.ID, req.ID, data, tx)
+	if err != nil {
+		u.log.Errorf("failed update transfer: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) Delete(user *userModel.User, id uint) (resp common.Response) {
+	db := config.GetDatabase()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	err = u.repo.Delete(user.ID, id, tx)
+	if err != nil {
+		u.log.Errorf("failed delete transfer: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) checkAccount(userId uint, db *sqlx.DB, ids ...uint) error {
+	for _, id := range ids {
+		if _, err := u.accountRepo.GetById(userId, id, db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
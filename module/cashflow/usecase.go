@@ -2,20 +2,28 @@ package cashflow
 
 import (
 	"context"
+	"database/sql"
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"strconv"
+	"slices"
+	"time"
 
 	"github.com/fazriegi/money_management-be/config"
 	"github.com/fazriegi/money_management-be/constant"
 	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/aggregate"
+	aggregateModel "github.com/fazriegi/money_management-be/module/cashflow/aggregate/model"
 	"github.com/fazriegi/money_management-be/module/cashflow/expense"
 	expenseModel "github.com/fazriegi/money_management-be/module/cashflow/expense/model"
 	"github.com/fazriegi/money_management-be/module/cashflow/income"
 	incomeModel "github.com/fazriegi/money_management-be/module/cashflow/income/model"
 	"github.com/fazriegi/money_management-be/module/cashflow/model"
 	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
 	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
@@ -23,19 +31,28 @@ import (
 
 type Usecase interface {
 	List(user *userModel.User, req *model.ListRequest) (resp common.Response)
+	Export(user *userModel.User, req *model.ListRequest) (resp common.Response, write func(w io.Writer) error)
+	Duplicates(user *userModel.User, req *model.DuplicateRequest) (resp common.Response)
+	ResolveDuplicates(user *userModel.User, req *model.ResolveDuplicateRequest) (resp common.Response)
 }
 
 type usecase struct {
 	log            *logrus.Logger
 	repo           Repository
+	periodRepo     period.Repository
+	currencyRepo   currency.Repository
+	aggregator     aggregate.Aggregator
 	incomeUsecase  income.Usecase
 	expenseUsecase expense.Usecase
 }
 
-func NewUsecase(log *logrus.Logger, repo Repository, incomeUsecase income.Usecase, expenseUsecase expense.Usecase) Usecase {
+func NewUsecase(log *logrus.Logger, repo Repository, periodRepo period.Repository, currencyRepo currency.Repository, aggregator aggregate.Aggregator, incomeUsecase income.Usecase, expenseUsecase expense.Usecase) Usecase {
 	return &usecase{
 		log,
 		repo,
+		periodRepo,
+		currencyRepo,
+		aggregator,
 		incomeUsecase,
 		expenseUsecase,
 	}
@@ -47,10 +64,35 @@ func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp commo
 	var (
 		totalData    uint
 		result       []model.CashflowData
-		totalIncome  float64
-		totalExpense float64
+		totalIncome  libs.Money
+		totalExpense libs.Money
 	)
 
+	userPeriod, err := u.periodRepo.GetPeriod(user.ID, db)
+	if err != nil {
+		u.log.Errorf("periodRepo.GetPeriod: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if req.Period != "" {
+		startDate, endDate, err := period.ResolveDates(u.periodRepo, user.ID, req.Period, db)
+		if err != nil && errors.Is(err, period.ErrInvalidPeriod) {
+			return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+		} else if err != nil {
+			u.log.Errorf("period.ResolveDates: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		req.StartDate = startDate
+		req.EndDate = endDate
+	}
+
+	converter, err := currency.NewConverter(user.ID, u.currencyRepo, db)
+	if err != nil {
+		u.log.Errorf("currency.NewConverter: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
 	g, _ := errgroup.WithContext(context.Background())
 
 	g.Go(func() error {
@@ -72,18 +114,21 @@ func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp commo
 				return errors.New("failed list data")
 			}
 
-			value, err := strconv.ParseFloat(decValue, 64)
+			value, err := libs.ParseMoney(decValue)
 			if err != nil {
 				u.log.Errorf("error parsing string: %s", err.Error())
 				return errors.New("failed list data")
 			}
 
 			resultData[i] = model.CashflowData{
-				ID:       data.ID,
-				Category: data.Category,
-				Date:     data.Date,
-				Value:    value,
-				Type:     data.Type,
+				ID:        data.ID,
+				Category:  data.Category,
+				Date:      data.Date,
+				Value:     value,
+				Type:      data.Type,
+				AccountId: data.AccountId,
+				Account:   data.Account,
+				Currency:  data.Currency,
 			}
 		}
 
@@ -93,10 +138,306 @@ func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp commo
 	})
 
 	g.Go(func() error {
-		resp = u.incomeUsecase.List(user, &incomeModel.ListRequest{
+		var err error
+		totalIncome, totalExpense, err = u.totals(user, userPeriod.DayOfMonth, req.StartDate, req.EndDate, converter)
+		return err
+	})
+
+	err = g.Wait()
+	if err != nil && errors.Is(err, currency.ErrRateNotFound) {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	} else if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	responseData := map[string]any{
+		"cashflow": map[string]any{
+			"data":           result,
+			"total_income":   totalIncome,
+			"total_expense":  totalExpense,
+			"total_cashflow": totalIncome.Sub(totalExpense),
+			"currency":       converter.Base(),
+		},
+		"total": totalData,
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", responseData)
+}
+
+// Export returns a writer for the cashflow rows matching the list filters as
+// CSV. The rows are read and decrypted one at a time while they are written.
+func (u *usecase) Export(user *userModel.User, req *model.ListRequest) (resp common.Response, write func(w io.Writer) error) {
+	db := config.GetDatabase()
+	key := fmt.Sprintf("%d", user.ID)
+
+	if req.Period != "" {
+		startDate, endDate, err := period.ResolveDates(u.periodRepo, user.ID, req.Period, db)
+		if err != nil && errors.Is(err, period.ErrInvalidPeriod) {
+			return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil), nil
+		} else if err != nil {
+			u.log.Errorf("period.ResolveDates: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil), nil
+		}
+
+		req.StartDate = startDate
+		req.EndDate = endDate
+	}
+
+	req.UserId = user.ID
+	rows, err := u.repo.Export(req, db)
+	if err != nil {
+		u.log.Errorf("repo.Export: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil), nil
+	}
+
+	write = func(w io.Writer) error {
+		defer rows.Close()
+
+		csvWriter := csv.NewWriter(w)
+		err := csvWriter.Write([]string{"date", "type", "category", "value", "currency", "account", "notes"})
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var data model.GetCashflow
+			if err := libs.ScanRowIntoStruct(rows, &data); err != nil {
+				return fmt.Errorf("failed to scan row into struct: %w", err)
+			}
+
+			decValue, err := libs.Decrypt(key, data.Value)
+			if err != nil {
+				return fmt.Errorf("error decrypting value: %w", err)
+			}
+
+			value, err := libs.ParseMoney(decValue)
+			if err != nil {
+				return fmt.Errorf("error parsing string: %w", err)
+			}
+
+			var currencyCode, account string
+			if data.Currency != nil {
+				currencyCode = *data.Currency
+			}
+
+			if data.Account != nil {
+				account = *data.Account
+			}
+
+			err = csvWriter.Write([]string{
+				libs.ParseDate(data.Date).Format(constant.DateFormat),
+				data.Type,
+				data.Category,
+				value.String(),
+				currencyCode,
+				account,
+				data.Notes,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return err
+		}
+
+		return rows.Err()
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil), write
+}
+
+// Duplicates finds the incomes and expenses of the period that are likely
+// recorded more than once. Values are encrypted, so they are compared after
+// decryption. Transactions up to Days outside the period are included, so a
+// duplicate dated just before the period start is still found.
+func (u *usecase) Duplicates(user *userModel.User, req *model.DuplicateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	key := fmt.Sprintf("%d", user.ID)
+
+	if req.Period == "" {
+		req.Period = period.PeriodCurrent
+	}
+
+	days := uint(defaultDuplicateDays)
+	if req.Days != nil {
+		days = *req.Days
+	}
+
+	periodRange, err := period.ResolveRange(u.periodRepo, user.ID, req.Period, db)
+	if err != nil && errors.Is(err, period.ErrInvalidPeriod) {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	} else if err != nil {
+		u.log.Errorf("period.ResolveRange: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	filter := model.ListFilter{
+		UserId:    user.ID,
+		StartDate: periodRange.StartDate.AddDate(0, 0, -int(days)).Format(constant.DateFormat),
+		EndDate:   periodRange.EndDate.AddDate(0, 0, int(days)).Format(constant.DateFormat),
+	}
+
+	transactions, err := u.repo.ListTransaction(&filter, req.Type, db)
+	if err != nil {
+		u.log.Errorf("repo.ListTransaction: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	candidates := make([]candidate, len(transactions))
+	for i, transaction := range transactions {
+		decValue, err := libs.Decrypt(key, transaction.Value)
+		if err != nil {
+			u.log.Errorf("error decrypting value: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		value, err := libs.ParseMoney(decValue)
+		if err != nil {
+			u.log.Errorf("error parsing string: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		candidates[i] = candidate{
+			GetTransaction: transaction,
+			date:           libs.ParseDate(transaction.Date),
+			value:          value,
+		}
+	}
+
+	result := model.Duplicates{
+		Period: periodRange,
+		Days:   days,
+		Groups: findDuplicates(
+			candidates,
+			days,
+			periodRange.StartDate.Format(constant.DateFormat),
+			periodRange.EndDate.Format(constant.DateFormat),
+		),
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+// ResolveDuplicates keeps one transaction and deletes the given duplicates of
+// it in one transaction, merging their details first when asked to
+func (u *usecase) ResolveDuplicates(user *userModel.User, req *model.ResolveDuplicateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	if slices.Contains(req.DuplicateIds, req.KeepId) {
+		return resp.CustomResponse(http.StatusBadRequest, "keep_id must not be one of duplicate_ids", nil)
+	}
+
+	duplicateIds := slices.Clone(req.DuplicateIds)
+	slices.Sort(duplicateIds)
+	duplicateIds = slices.Compact(duplicateIds)
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	merge := req.Action == model.ResolveMerge
+	if req.Type == model.TypeIncome {
+		err = u.incomeUsecase.ResolveDuplicateTx(user, req.KeepId, duplicateIds, merge, tx)
+	} else {
+		err = u.expenseUsecase.ResolveDuplicateTx(user, req.KeepId, duplicateIds, merge, tx)
+	}
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, fmt.Sprintf("%s not found", req.Type), nil)
+	} else if err != nil && errors.Is(err, model.ErrNotDuplicate) {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	} else if err != nil {
+		u.log.Errorf("failed resolve duplicate %s: %s", req.Type, err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+// totals sums the incomes and expenses between the dates in the base
+// currency. Ranges made of whole periods are summed from the aggregates,
+// other ranges fall back to summing the transactions.
+func (u *usecase) totals(user *userModel.User, dayOfMonth uint8, startDate, endDate string, converter *currency.Converter) (totalIncome, totalExpense libs.Money, err error) {
+	db := config.GetDatabase()
+
+	if !isWholePeriods(dayOfMonth, startDate, endDate) {
+		return u.sumTransactions(user, startDate, endDate, "", converter)
+	}
+
+	fresh, err := u.aggregator.IsFresh(user.ID, dayOfMonth, db)
+	if err != nil {
+		return totalIncome, totalExpense, fmt.Errorf("aggregator.IsFresh: %w", err)
+	}
+
+	if !fresh {
+		err = u.aggregator.Rebuild(user.ID, dayOfMonth, func() ([]aggregateModel.Entry, error) {
+			return u.listEntries(user)
+		}, db)
+		if err != nil {
+			return totalIncome, totalExpense, fmt.Errorf("aggregator.Rebuild: %w", err)
+		}
+	}
+
+	aggregates, err := u.aggregator.List(user.ID, startDate, endDate, db)
+	if err != nil {
+		return totalIncome, totalExpense, fmt.Errorf("aggregator.List: %w", err)
+	}
+
+	// an aggregate mixes dates with different rates, so amounts in another
+	// currency are still converted per transaction
+	foreignCurrencies := make([]string, 0)
+	for _, data := range aggregates {
+		if data.Currency != converter.Base() {
+			if !slices.Contains(foreignCurrencies, data.Currency) {
+				foreignCurrencies = append(foreignCurrencies, data.Currency)
+			}
+			continue
+		}
+
+		switch data.Type {
+		case model.TypeIncome:
+			totalIncome = totalIncome.Add(data.Value)
+		case model.TypeExpense:
+			totalExpense = totalExpense.Add(data.Value)
+		}
+	}
+
+	for _, currencyCode := range foreignCurrencies {
+		income, expense, err := u.sumTransactions(user, startDate, endDate, currencyCode, converter)
+		if err != nil {
+			return totalIncome, totalExpense, err
+		}
+
+		totalIncome = totalIncome.Add(income)
+		totalExpense = totalExpense.Add(expense)
+	}
+
+	return
+}
+
+// sumTransactions sums the incomes and expenses between the dates by reading
+// every transaction, optionally only those in one currency
+func (u *usecase) sumTransactions(user *userModel.User, startDate, endDate, currencyCode string, converter *currency.Converter) (totalIncome, totalExpense libs.Money, err error) {
+	g, _ := errgroup.WithContext(context.Background())
+
+	g.Go(func() error {
+		resp := u.incomeUsecase.List(user, &incomeModel.ListRequest{
 			UserId:    user.ID,
-			StartDate: req.StartDate,
-			EndDate:   req.EndDate,
+			StartDate: startDate,
+			EndDate:   endDate,
+			Currency:  currencyCode,
 		})
 		if !resp.IsSuccess {
 			return errors.New("failed calculate total income")
@@ -105,17 +446,23 @@ func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp commo
 		data := resp.Data.([]incomeModel.IncomeData)
 
 		for _, v := range data {
-			totalIncome += v.Value
+			value, err := converter.Convert(v.Value, v.Currency, libs.ParseDate(v.Date))
+			if err != nil {
+				return err
+			}
+
+			totalIncome = totalIncome.Add(value)
 		}
 
 		return nil
 	})
 
 	g.Go(func() error {
-		resp = u.expenseUsecase.List(user, &expenseModel.ListRequest{
+		resp := u.expenseUsecase.List(user, &expenseModel.ListRequest{
 			UserId:    user.ID,
-			StartDate: req.StartDate,
-			EndDate:   req.EndDate,
+			StartDate: startDate,
+			EndDate:   endDate,
+			Currency:  currencyCode,
 		})
 		if !resp.IsSuccess {
 			return errors.New("failed calculate total expense")
@@ -124,27 +471,78 @@ func (u *usecase) List(user *userModel.User, req *model.ListRequest) (resp commo
 		data := resp.Data.([]expenseModel.ExpenseData)
 
 		for _, v := range data {
-			totalExpense += v.Value
+			value, err := converter.Convert(v.Value, v.Currency, libs.ParseDate(v.Date))
+			if err != nil {
+				return err
+			}
+
+			totalExpense = totalExpense.Add(value)
 		}
 
 		return nil
 	})
 
-	err := g.Wait()
+	err = g.Wait()
+	return
+}
+
+// listEntries returns every income and expense of the user as aggregate
+// entries, used to rebuild the aggregates
+func (u *usecase) listEntries(user *userModel.User) ([]aggregateModel.Entry, error) {
+	incomeResp := u.incomeUsecase.List(user, &incomeModel.ListRequest{})
+	if !incomeResp.IsSuccess {
+		return nil, errors.New("failed list income")
+	}
+
+	expenseResp := u.expenseUsecase.List(user, &expenseModel.ListRequest{})
+	if !expenseResp.IsSuccess {
+		return nil, errors.New("failed list expense")
+	}
+
+	incomes := incomeResp.Data.([]incomeModel.IncomeData)
+	expenses := expenseResp.Data.([]expenseModel.ExpenseData)
+
+	result := make([]aggregateModel.Entry, 0, len(incomes)+len(expenses))
+	for _, v := range incomes {
+		result = append(result, aggregateModel.Entry{
+			Type:       model.TypeIncome,
+			CategoryId: v.CategoryId,
+			Date:       libs.ParseDate(v.Date),
+			Currency:   v.Currency,
+			Value:      v.Value,
+		})
+	}
+
+	for _, v := range expenses {
+		result = append(result, aggregateModel.Entry{
+			Type:       model.TypeExpense,
+			CategoryId: v.CategoryId,
+			Date:       libs.ParseDate(v.Date),
+			Currency:   v.Currency,
+			Value:      v.Value,
+		})
+	}
+
+	return result, nil
+}
+
+// isWholePeriods reports whether the range starts at a period start and ends
+// at a period end. No range at all covers every period.
+func isWholePeriods(dayOfMonth uint8, startDate, endDate string) bool {
+	if startDate == "" || endDate == "" {
+		return true
+	}
+
+	start, err := time.Parse(constant.DateFormat, startDate)
 	if err != nil {
-		u.log.Errorf("%s", err.Error())
-		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		return false
 	}
 
-	responseData := map[string]any{
-		"cashflow": map[string]any{
-			"data":           result,
-			"total_income":   totalIncome,
-			"total_expense":  totalExpense,
-			"total_cashflow": totalIncome - totalExpense,
-		},
-		"total": totalData,
+	end, err := time.Parse(constant.DateFormat, endDate)
+	if err != nil {
+		return false
 	}
 
-	return resp.CustomResponse(http.StatusOK, "success", responseData)
+	return period.GetRangeOf(dayOfMonth, start).StartDate.Format(constant.DateFormat) == startDate &&
+		period.GetRangeOf(dayOfMonth, end).EndDate.Format(constant.DateFormat) == endDate
 }
@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/importer/model"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type Controller interface {
+	PreviewCSV(ctx *fiber.Ctx) error
+	ImportCSV(ctx *fiber.Ctx) error
+	PreviewStatement(ctx *fiber.Ctx) error
+	ImportStatement(ctx *fiber.Ctx) error
+}
+
+type controller struct {
+	log     *logrus.Logger
+	usecase Usecase
+}
+
+func NewController(log *logrus.Logger, usecase Usecase) Controller {
+	return &controller{
+		log,
+		usecase,
+	}
+}
+
+func (c *controller) PreviewCSV(ctx *fiber.Ctx) error {
+	return c.handleCSV(ctx, c.usecase.PreviewCSV)
+}
+
+func (c *controller) ImportCSV(ctx *fiber.Ctx) error {
+	return c.handleCSV(ctx, c.usecase.ImportCSV)
+}
+
+func (c *controller) PreviewStatement(ctx *fiber.Ctx) error {
+	return c.handleStatement(ctx, c.usecase.PreviewStatement)
+}
+
+func (c *controller) ImportStatement(ctx *fiber.Ctx) error {
+	return c.handleStatement(ctx, c.usecase.ImportStatement)
+}
+
+// handleCSV reads the uploaded file and its column mapping, preview and
+// import take the same form so the previewed request can be sent again to
+// confirm it
+func (c *controller) handleCSV(ctx *fiber.Ctx, handle func(user *userModel.User, file io.Reader, mapping *model.CSVMapping) common.Response) error {
+	var (
+		response common.Response
+		reqBody  model.CSVMapping
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing request body: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseReqBodyErr, nil))
+	}
+
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		c.log.Errorf("error get form file: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, "file is required", nil))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.log.Errorf("error open form file: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, "invalid file", nil))
+	}
+	defer file.Close()
+
+	response = handle(&user, file, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
+
+func (c *controller) handleStatement(ctx *fiber.Ctx, handle func(user *userModel.User, file io.Reader, req *model.StatementRequest) common.Response) error {
+	var (
+		response common.Response
+		reqBody  model.StatementRequest
+
+		user = ctx.Locals("user").(userModel.User)
+	)
+
+	if err := ctx.BodyParser(&reqBody); err != nil {
+		c.log.Errorf("error parsing request body: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, constant.ParseReqBodyErr, nil))
+	}
+
+	// validate reqBody struct
+	validationErr := libs.ValidateRequest(&reqBody)
+	if len(validationErr) > 0 {
+		errResponse := map[string]any{
+			"errors": validationErr,
+		}
+
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(response.CustomResponse(http.StatusUnprocessableEntity, constant.ValidationErr, errResponse))
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		c.log.Errorf("error get form file: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, "file is required", nil))
+	}
+
+	if reqBody.Format == "" {
+		switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+		case ".ofx", ".qfx":
+			reqBody.Format = model.FormatOFX
+		case ".qif":
+			reqBody.Format = model.FormatQIF
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.log.Errorf("error open form file: %s", err.Error())
+		return ctx.Status(http.StatusBadRequest).JSON(response.CustomResponse(http.StatusBadRequest, "invalid file", nil))
+	}
+	defer file.Close()
+
+	response = handle(&user, file, &reqBody)
+
+	return ctx.Status(response.Status.Code).JSON(response)
+}
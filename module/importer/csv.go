@@ -0,0 +1,259 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/importer/model"
+)
+
+const (
+	maxNotesLength    = 255
+	maxCategoryLength = 50
+)
+
+var dateLayouts = map[string]string{
+	"YYYY-MM-DD": "2006-01-02",
+	"DD/MM/YYYY": "02/01/2006",
+	"MM/DD/YYYY": "01/02/2006",
+	"DD-MM-YYYY": "02-01-2006",
+	"YYYY/MM/DD": "2006/01/02",
+	"DD.MM.YYYY": "02.01.2006",
+}
+
+var (
+	incomeTypeValues  = []string{"income", "credit", "cr", "in"}
+	expenseTypeValues = []string{"expense", "debit", "dr", "db", "out"}
+)
+
+// parseCSV reads every line of the file with the mapping. Lines that cannot
+// be read keep their errors so they can be shown in the preview.
+func parseCSV(file io.Reader, mapping *model.CSVMapping) ([]model.Row, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	if mapping.Delimiter != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(mapping.Delimiter)
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("file is not a valid csv")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+
+	for _, name := range []string{
+		mapping.DateColumn,
+		mapping.AmountColumn,
+		mapping.DebitColumn,
+		mapping.CreditColumn,
+		mapping.TypeColumn,
+		mapping.DescriptionColumn,
+		mapping.CategoryColumn,
+	} {
+		if name == "" {
+			continue
+		}
+
+		if _, ok := columns[strings.ToLower(strings.TrimSpace(name))]; !ok {
+			return nil, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	column := func(record []string, name string) string {
+		idx, ok := columns[strings.ToLower(strings.TrimSpace(name))]
+		if name == "" || !ok || idx >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[idx])
+	}
+
+	layout := dateLayouts[mapping.DateFormat]
+	if layout == "" {
+		layout = constant.DateFormat
+	}
+
+	var (
+		result = make([]model.Row, 0)
+		line   = 1
+	)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+
+		if err != nil {
+			result = append(result, model.Row{Line: line, Errors: []string{err.Error()}})
+			continue
+		}
+
+		row := model.Row{
+			Line:     line,
+			Notes:    truncate(column(record, mapping.DescriptionColumn), maxNotesLength),
+			Category: column(record, mapping.CategoryColumn),
+		}
+
+		date, err := time.Parse(layout, column(record, mapping.DateColumn))
+		if err != nil {
+			row.Errors = append(row.Errors, "date does not match the date format")
+		} else {
+			row.Date = date.Format(constant.DateFormat)
+		}
+
+		row.Type, row.Value, err = parseValue(record, column, mapping)
+		if err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		}
+
+		if utf8.RuneCountInString(row.Category) > maxCategoryLength {
+			row.Errors = append(row.Errors, fmt.Sprintf("category is longer than %d characters", maxCategoryLength))
+		}
+
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+// parseValue returns the type of a row and its amount as a positive value
+func parseValue(record []string, column func(record []string, name string) string, mapping *model.CSVMapping) (string, libs.Money, error) {
+	switch mapping.Sign {
+	case model.SignSplit:
+		debit, credit := column(record, mapping.DebitColumn), column(record, mapping.CreditColumn)
+		if (debit == "") == (credit == "") {
+			return "", libs.Money{}, errors.New("exactly one of debit and credit must be filled")
+		}
+
+		rowType, raw := cashflowModel.TypeExpense, debit
+		if credit != "" {
+			rowType, raw = cashflowModel.TypeIncome, credit
+		}
+
+		value, err := parseAmount(raw, mapping.DecimalSeparator)
+		if err != nil {
+			return "", libs.Money{}, err
+		}
+
+		if value.Sign() < 0 {
+			value = value.Neg()
+		}
+
+		return rowType, value, nil
+	case model.SignColumn:
+		value, err := parseAmount(column(record, mapping.AmountColumn), mapping.DecimalSeparator)
+		if err != nil {
+			return "", libs.Money{}, err
+		}
+
+		if value.Sign() < 0 {
+			value = value.Neg()
+		}
+
+		typeValue := strings.ToLower(column(record, mapping.TypeColumn))
+		switch {
+		case slices.Contains(incomeTypeValues, typeValue):
+			return cashflowModel.TypeIncome, value, nil
+		case slices.Contains(expenseTypeValues, typeValue):
+			return cashflowModel.TypeExpense, value, nil
+		}
+
+		return "", libs.Money{}, fmt.Errorf("unknown type %q", typeValue)
+	}
+
+	value, err := parseAmount(column(record, mapping.AmountColumn), mapping.DecimalSeparator)
+	if err != nil {
+		return "", libs.Money{}, err
+	}
+
+	negative := value.Sign() < 0
+	if negative {
+		value = value.Neg()
+	}
+
+	if negative == (mapping.Sign == model.SignNegativeIncome) {
+		return cashflowModel.TypeIncome, value, nil
+	}
+
+	return cashflowModel.TypeExpense, value, nil
+}
+
+// parseAmount reads amounts the way banks print them, with thousands
+// separators, a currency symbol or parentheses for negative values
+func parseAmount(raw, decimalSeparator string) (libs.Money, error) {
+	value := strings.TrimSpace(raw)
+
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+	value = strings.Trim(value, "()")
+
+	if decimalSeparator == "" {
+		decimalSeparator = "."
+	}
+
+	thousandsSeparator := ","
+	if decimalSeparator == "," {
+		thousandsSeparator = "."
+	}
+
+	value = strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r == '-', r == '+':
+			return r
+		case string(r) == decimalSeparator:
+			return '.'
+		case string(r) == thousandsSeparator:
+			return -1
+		case r == ' ' || r == '\u00a0' || r == '\'':
+			return -1
+		}
+
+		// currency symbols and codes such as Rp or $
+		if r > 127 || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || r == '$' {
+			return -1
+		}
+
+		return r
+	}, value)
+
+	amount, err := libs.ParseMoney(value)
+	if err != nil {
+		return libs.Money{}, fmt.Errorf("invalid amount %q", raw)
+	}
+
+	if amount.IsZero() {
+		return libs.Money{}, errors.New("amount is zero")
+	}
+
+	if negative {
+		amount = amount.Neg()
+	}
+
+	return amount, nil
+}
+
+func truncate(value string, length int) string {
+	if utf8.RuneCountInString(value) <= length {
+		return value
+	}
+
+	return string([]rune(value)[:length])
+}
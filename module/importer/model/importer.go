@@ -0,0 +1,75 @@
+package model
+
+import "github.com/fazriegi/money_management-be/libs"
+
+const (
+	SignNegativeExpense = "negative_expense"
+	SignNegativeIncome  = "negative_income"
+	SignColumn          = "column"
+	SignSplit           = "split"
+
+	FormatOFX = "ofx"
+	FormatQIF = "qif"
+
+	// UncategorizedName is the category given to rows that name none
+	UncategorizedName = "Uncategorized"
+)
+
+// CSVMapping tells which header names hold each field. Sign decides whether
+// a row is an income or an expense: by the sign of the amount, by a type
+// column, or by separate debit and credit columns.
+type CSVMapping struct {
+	DateColumn        string `form:"date_column" validate:"required"`
+	AmountColumn      string `form:"amount_column" validate:"required_unless=Sign split"`
+	DebitColumn       string `form:"debit_column" validate:"required_if=Sign split"`
+	CreditColumn      string `form:"credit_column" validate:"required_if=Sign split"`
+	TypeColumn        string `form:"type_column" validate:"required_if=Sign column"`
+	DescriptionColumn string `form:"description_column"`
+	CategoryColumn    string `form:"category_column"`
+	Sign              string `form:"sign" validate:"omitempty,oneof=negative_expense negative_income column split"`
+	DateFormat        string `form:"date_format" validate:"omitempty,oneof=YYYY-MM-DD DD/MM/YYYY MM/DD/YYYY DD-MM-YYYY YYYY/MM/DD DD.MM.YYYY"`
+	DecimalSeparator  string `form:"decimal_separator" validate:"omitempty,oneof=. ,"`
+	Delimiter         string `form:"delimiter" validate:"omitempty,len=1"`
+	Currency          string `form:"currency" validate:"omitempty,iso4217"`
+	AccountId         *uint  `form:"account_id"`
+}
+
+// StatementRequest describes an OFX or QIF bank statement. The format is
+// detected from the file when it is not given, DateFormat is only used by
+// QIF since OFX dates have a fixed format.
+type StatementRequest struct {
+	Format     string `form:"format" validate:"omitempty,oneof=ofx qif"`
+	DateFormat string `form:"date_format" validate:"omitempty,oneof=YYYY-MM-DD DD/MM/YYYY MM/DD/YYYY DD-MM-YYYY YYYY/MM/DD DD.MM.YYYY"`
+	Currency   string `form:"currency" validate:"omitempty,iso4217"`
+	AccountId  *uint  `form:"account_id"`
+}
+
+// Row is one parsed statement line. CategoryId is empty when the category
+// does not exist yet and will be created on import. ExternalId identifies a
+// statement entry so importing it again is detected as a duplicate.
+type Row struct {
+	Line       int        `json:"line"`
+	Type       string     `json:"type"`
+	Date       string     `json:"date"`
+	Value      libs.Money `json:"value"`
+	Notes      string     `json:"notes"`
+	Category   string     `json:"category"`
+	CategoryId *uint      `json:"category_id"`
+	ExternalId string     `json:"external_id,omitempty"`
+	Duplicate  bool       `json:"duplicate"`
+	Errors     []string   `json:"errors,omitempty"`
+}
+
+type NewCategory struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type Preview struct {
+	Rows          []Row         `json:"rows"`
+	Total         int           `json:"total"`
+	Valid         int           `json:"valid"`
+	Invalid       int           `json:"invalid"`
+	Duplicates    int           `json:"duplicates"`
+	NewCategories []NewCategory `json:"new_categories"`
+}
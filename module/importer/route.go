@@ -0,0 +1,38 @@
+package importer
+
+import (
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/middleware"
+	"github.com/fazriegi/money_management-be/module/cashflow/aggregate"
+	"github.com/fazriegi/money_management-be/module/cashflow/expense"
+	"github.com/fazriegi/money_management-be/module/cashflow/income"
+	"github.com/fazriegi/money_management-be/module/cashflow/rule"
+	"github.com/fazriegi/money_management-be/module/master/account"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	"github.com/fazriegi/money_management-be/module/master/tag"
+	"github.com/gofiber/fiber/v2"
+)
+
+func NewRoute(app *fiber.App, jwt *libs.JWT) {
+	log := config.GetLogger()
+	incomeRepo := income.NewRepository()
+	expenseRepo := expense.NewRepository()
+	periodRepo := period.NewRepository()
+	accountRepo := account.NewRepository()
+	currencyRepo := currency.NewRepository()
+	aggregator := aggregate.NewAggregator(aggregate.NewRepository())
+	tagRepo := tag.NewRepository()
+	matcher := rule.NewMatcher(rule.NewRepository())
+	incomeUsecase := income.NewUsecase(log, incomeRepo, periodRepo, accountRepo, currencyRepo, tagRepo, aggregator, matcher)
+	expenseUsecase := expense.NewUsecase(log, expenseRepo, periodRepo, accountRepo, currencyRepo, tagRepo, aggregator, matcher)
+	usecase := NewUsecase(log, incomeRepo, expenseRepo, accountRepo, currencyRepo, incomeUsecase, expenseUsecase, matcher)
+	controller := NewController(log, usecase)
+
+	route := app.Group("/import")
+	route.Post("/csv", middleware.Authentication(jwt), controller.PreviewCSV)
+	route.Post("/csv/confirm", middleware.Authentication(jwt), controller.ImportCSV)
+	route.Post("/statement", middleware.Authentication(jwt), controller.PreviewStatement)
+	route.Post("/statement/confirm", middleware.Authentication(jwt), controller.ImportStatement)
+}
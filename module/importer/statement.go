@@ -0,0 +1,355 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/importer/model"
+)
+
+const maxExternalIdLength = 255
+
+var (
+	ErrUnknownFormat = errors.New("unknown statement format, expected OFX or QIF")
+
+	ofxTransaction = regexp.MustCompile(`(?is)<STMTTRN>(.*?)</STMTTRN>`)
+	currencyCode   = regexp.MustCompile(`^[A-Z]{3}$`)
+
+	ofxCurrency = ofxFieldPattern("CURDEF")
+	ofxAccount  = ofxFieldPattern("ACCTID")
+	ofxName     = ofxFieldPattern("NAME")
+	ofxMemo     = ofxFieldPattern("MEMO")
+	ofxPosted   = ofxFieldPattern("DTPOSTED")
+	ofxAmount   = ofxFieldPattern("TRNAMT")
+	ofxFitId    = ofxFieldPattern("FITID")
+
+	// QIF sections that hold transactions, other sections such as
+	// investments or the account list are skipped
+	qifTransactionTypes = []string{"bank", "cash", "ccard", "oth a", "oth l"}
+)
+
+// parseStatement reads an OFX or QIF statement into rows. The currency is
+// the one declared by an OFX statement, QIF files do not declare one.
+func parseStatement(file io.Reader, req *model.StatementRequest) (rows []model.Row, currency string, err error) {
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, "", errors.New("invalid file")
+	}
+
+	format := req.Format
+	if format == "" {
+		format = detectFormat(content)
+	}
+
+	switch format {
+	case model.FormatOFX:
+		return parseOFX(content)
+	case model.FormatQIF:
+		rows, err = parseQIF(content, req.DateFormat)
+		return rows, "", err
+	}
+
+	return nil, "", ErrUnknownFormat
+}
+
+func detectFormat(content []byte) string {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\ufeff")))
+
+	switch {
+	case bytes.Contains(bytes.ToUpper(trimmed), []byte("<OFX>")):
+		return model.FormatOFX
+	case bytes.HasPrefix(trimmed, []byte("!")):
+		return model.FormatQIF
+	}
+
+	return ""
+}
+
+// parseOFX reads the transactions of OFX 1.x (SGML) and 2.x (XML) files.
+// Every value sits right after its opening tag in both versions.
+func parseOFX(content []byte) ([]model.Row, string, error) {
+	text := string(content)
+
+	blocks := ofxTransaction.FindAllStringSubmatch(text, -1)
+	if len(blocks) == 0 && !strings.Contains(strings.ToUpper(text), "<BANKTRANLIST>") {
+		return nil, "", errors.New("file is not a valid ofx statement")
+	}
+
+	currency := strings.ToUpper(ofxField(text, ofxCurrency))
+	if !currencyCode.MatchString(currency) {
+		currency = ""
+	}
+
+	accountId := ofxField(text, ofxAccount)
+
+	result := make([]model.Row, 0, len(blocks))
+	occurrences := make(map[string]int)
+	for i, block := range blocks {
+		body := block[1]
+
+		row := model.Row{
+			Line:  i + 1,
+			Notes: joinNotes(ofxField(body, ofxName), ofxField(body, ofxMemo)),
+		}
+
+		posted := ofxField(body, ofxPosted)
+		date, err := time.Parse("20060102", leadingDigits(posted, 8))
+		if err != nil {
+			row.Errors = append(row.Errors, "invalid DTPOSTED")
+		} else {
+			row.Date = date.Format(constant.DateFormat)
+		}
+
+		amount := ofxField(body, ofxAmount)
+		row.Type, row.Value, err = statementValue(amount)
+		if err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		}
+
+		fitId := ofxField(body, ofxFitId)
+		if fitId != "" {
+			row.ExternalId = truncate("ofx:"+accountId+":"+fitId, maxExternalIdLength)
+		} else {
+			row.ExternalId = syntheticId("ofx:"+accountId, occurrences, posted, amount, row.Notes)
+		}
+
+		result = append(result, row)
+	}
+
+	return result, currency, nil
+}
+
+// ofxFieldPattern matches the value of a tag, which SGML files leave unclosed
+func ofxFieldPattern(tag string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)<` + tag + `>\s*([^<\r\n]*)`)
+}
+
+func ofxField(text string, pattern *regexp.Regexp) string {
+	match := pattern.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(match[1])
+}
+
+// parseQIF reads the bank, cash and credit card sections of a QIF file.
+// QIF entries carry no id, so one is derived from their content.
+func parseQIF(content []byte, dateFormat string) ([]model.Row, error) {
+	if dateFormat == "" {
+		dateFormat = "MM/DD/YYYY"
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(content, []byte("\ufeff"))))
+
+	var (
+		result      = make([]model.Row, 0)
+		occurrences = make(map[string]int)
+		fields      = make(map[byte]string)
+		inSection   bool
+		hasHeader   bool
+		line        int
+		startLine   int
+	)
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "!") {
+			header := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(text, "!")))
+			if strings.HasPrefix(header, "type:") {
+				hasHeader = true
+				inSection = false
+				for _, sectionType := range qifTransactionTypes {
+					if strings.TrimSpace(strings.TrimPrefix(header, "type:")) == sectionType {
+						inSection = true
+					}
+				}
+			} else if header == "account" {
+				inSection = false
+			}
+
+			fields = make(map[byte]string)
+			continue
+		}
+
+		if text == "^" {
+			if inSection && len(fields) > 0 {
+				result = append(result, qifRow(startLine, fields, dateFormat, occurrences))
+			}
+
+			fields = make(map[byte]string)
+			continue
+		}
+
+		if len(fields) == 0 {
+			startLine = line
+		}
+
+		// split lines (S, E and $) repeat per split and are not kept
+		code := text[0]
+		if _, ok := fields[code]; !ok {
+			fields[code] = strings.TrimSpace(text[1:])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("file is not a valid qif statement")
+	}
+
+	if !hasHeader {
+		return nil, errors.New("file is not a valid qif statement")
+	}
+
+	return result, nil
+}
+
+func qifRow(line int, fields map[byte]string, dateFormat string, occurrences map[string]int) model.Row {
+	row := model.Row{
+		Line:  line,
+		Notes: joinNotes(fields['P'], fields['M']),
+	}
+
+	// a category in brackets is a transfer to another account
+	if category := fields['L']; !strings.HasPrefix(category, "[") {
+		row.Category = category
+		if utf8.RuneCountInString(row.Category) > maxCategoryLength {
+			row.Errors = append(row.Errors, fmt.Sprintf("category is longer than %d characters", maxCategoryLength))
+		}
+	}
+
+	date, err := parseLooseDate(fields['D'], dateFormat)
+	if err != nil {
+		row.Errors = append(row.Errors, "date does not match the date format")
+	} else {
+		row.Date = date.Format(constant.DateFormat)
+	}
+
+	amount := fields['T']
+	if amount == "" {
+		amount = fields['U']
+	}
+
+	row.Type, row.Value, err = statementValue(amount)
+	if err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+
+	row.ExternalId = syntheticId("qif", occurrences, fields['D'], amount, fields['P'], fields['M'], fields['N'])
+
+	return row
+}
+
+// statementValue turns a signed statement amount into a type, credits are
+// incomes and debits are expenses
+func statementValue(amount string) (string, libs.Money, error) {
+	decimalSeparator := "."
+	if strings.Contains(amount, ",") && !strings.Contains(amount, ".") {
+		decimalSeparator = ","
+	}
+
+	value, err := parseAmount(amount, decimalSeparator)
+	if err != nil {
+		return "", libs.Money{}, err
+	}
+
+	if value.Sign() < 0 {
+		return cashflowModel.TypeExpense, value.Neg(), nil
+	}
+
+	return cashflowModel.TypeIncome, value, nil
+}
+
+// parseLooseDate reads dates written with any separator, without zero
+// padding or with two digit years such as 1/5'26
+func parseLooseDate(value, dateFormat string) (time.Time, error) {
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if len(parts) != 3 {
+		return time.Time{}, errors.New("invalid date")
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return time.Time{}, errors.New("invalid date")
+		}
+		numbers[i] = number
+	}
+
+	var year, month, day int
+	switch {
+	case strings.HasPrefix(dateFormat, "YYYY"):
+		year, month, day = numbers[0], numbers[1], numbers[2]
+	case strings.HasPrefix(dateFormat, "MM"):
+		month, day, year = numbers[0], numbers[1], numbers[2]
+	default:
+		day, month, year = numbers[0], numbers[1], numbers[2]
+	}
+
+	// two digit years from 70 on are read as 19xx, so older exports keep
+	// their century
+	if year < 70 {
+		year += 2000
+	} else if year < 100 {
+		year += 1900
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Year() != year || int(date.Month()) != month || date.Day() != day {
+		return time.Time{}, errors.New("invalid date")
+	}
+
+	return date, nil
+}
+
+// syntheticId derives an id for entries without one. Identical entries in a
+// statement are told apart by their position among each other, so an
+// overlapping statement produces the same ids for them.
+func syntheticId(prefix string, occurrences map[string]int, values ...string) string {
+	key := strings.Join(values, "\x00")
+	occurrences[key]++
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", key, occurrences[key])))
+
+	return prefix + ":" + hex.EncodeToString(sum[:16])
+}
+
+func joinNotes(name, memo string) string {
+	notes := name
+	if memo != "" && memo != name {
+		if notes != "" {
+			notes += " - "
+		}
+		notes += memo
+	}
+
+	return truncate(notes, maxNotesLength)
+}
+
+func leadingDigits(value string, length int) string {
+	if len(value) < length {
+		return value
+	}
+
+	return value[:length]
+}
@@ -0,0 +1,267 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/importer/model"
+)
+
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+ENCODING:USASCII
+
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<CURDEF>usd
+<BANKACCTFROM>
+<ACCTID>123456
+</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260105120000[-5:EST]
+<TRNAMT>-42.50
+<FITID>T1
+<NAME>Coffee Shop
+<MEMO>Card 1234
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20260106
+<TRNAMT>1500.00
+<FITID>T2
+<NAME>Salary
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+const ofxXML = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+  <BANKMSGSRSV1>
+    <STMTTRNRS>
+      <STMTRS>
+        <CURDEF>USD</CURDEF>
+        <BANKACCTFROM>
+          <ACCTID>123456</ACCTID>
+        </BANKACCTFROM>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <TRNTYPE>DEBIT</TRNTYPE>
+            <DTPOSTED>20260105120000.000[-5:EST]</DTPOSTED>
+            <TRNAMT>-42.50</TRNAMT>
+            <FITID>T1</FITID>
+            <NAME>Coffee Shop</NAME>
+            <MEMO>Card 1234</MEMO>
+          </STMTTRN>
+          <STMTTRN>
+            <TRNTYPE>CREDIT</TRNTYPE>
+            <DTPOSTED>20260106</DTPOSTED>
+            <TRNAMT>1500.00</TRNAMT>
+            <FITID>T2</FITID>
+            <NAME>Salary</NAME>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </STMTRS>
+    </STMTTRNRS>
+  </BANKMSGSRSV1>
+</OFX>
+`
+
+type wantRow struct {
+	transactionType string
+	date            string
+	value           string
+	notes           string
+	category        string
+	externalId      string
+}
+
+func checkRows(t *testing.T, got []model.Row, want []wantRow) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(got), len(want), got)
+	}
+
+	for i, row := range got {
+		if len(row.Errors) > 0 {
+			t.Errorf("row %d has errors %v", i, row.Errors)
+		}
+
+		w := want[i]
+		if row.Type != w.transactionType || row.Date != w.date || row.Value.String() != w.value || row.Notes != w.notes || row.Category != w.category {
+			t.Errorf("row %d = {%s %s %s %q %q}, want {%s %s %s %q %q}",
+				i, row.Type, row.Date, row.Value, row.Notes, row.Category,
+				w.transactionType, w.date, w.value, w.notes, w.category)
+		}
+
+		if w.externalId != "" && row.ExternalId != w.externalId {
+			t.Errorf("row %d external id = %q, want %q", i, row.ExternalId, w.externalId)
+		}
+	}
+}
+
+func TestParseStatementOFX(t *testing.T) {
+	want := []wantRow{
+		{cashflowModel.TypeExpense, "2026-01-05", "42.5", "Coffee Shop - Card 1234", "", "ofx:123456:T1"},
+		{cashflowModel.TypeIncome, "2026-01-06", "1500", "Salary", "", "ofx:123456:T2"},
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"sgml", ofxSGML},
+		{"xml", ofxXML},
+		{"sgml with bom", "\ufeff" + ofxSGML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, currency, err := parseStatement(strings.NewReader(tt.content), &model.StatementRequest{})
+			if err != nil {
+				t.Fatalf("parseStatement() error = %v", err)
+			}
+
+			if currency != "USD" {
+				t.Errorf("currency = %q, want USD", currency)
+			}
+
+			checkRows(t, rows, want)
+		})
+	}
+}
+
+func TestParseStatementOFXWithoutFITID(t *testing.T) {
+	content := strings.ReplaceAll(ofxSGML, "<FITID>T1\n", "")
+	content = strings.ReplaceAll(content, "<FITID>T2\n", "")
+
+	first, _, err := parseStatement(strings.NewReader(content), &model.StatementRequest{})
+	if err != nil {
+		t.Fatalf("parseStatement() error = %v", err)
+	}
+
+	second, _, err := parseStatement(strings.NewReader(content), &model.StatementRequest{})
+	if err != nil {
+		t.Fatalf("parseStatement() error = %v", err)
+	}
+
+	if first[0].ExternalId == "" || first[0].ExternalId == first[1].ExternalId {
+		t.Errorf("synthetic ids %q and %q must be set and differ", first[0].ExternalId, first[1].ExternalId)
+	}
+
+	if first[0].ExternalId != second[0].ExternalId {
+		t.Errorf("synthetic id changed between runs: %q, %q", first[0].ExternalId, second[0].ExternalId)
+	}
+}
+
+func TestParseStatementQIF(t *testing.T) {
+	tests := []struct {
+		name       string
+		dateFormat string
+		dates      [2]string
+	}{
+		{"default month first", "", [2]string{"1/5/2026", "1/16/2026"}},
+		{"month first with apostrophe year", "MM/DD/YYYY", [2]string{"1/5'26", "1/16'26"}},
+		{"day first", "DD/MM/YYYY", [2]string{"05/01/2026", "16/01/2026"}},
+		{"day first with dots", "DD.MM.YYYY", [2]string{"5.1.2026", "16.1.2026"}},
+		{"year first", "YYYY-MM-DD", [2]string{"2026-01-05", "2026-01-16"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "!Type:Bank\n" +
+				"D" + tt.dates[0] + "\nT-42.50\nPCoffee Shop\nMCard 1234\nLFood\n^\n" +
+				"D" + tt.dates[1] + "\nT1,500.00\nPSalary\nLIncome:Salary\n^\n"
+
+			rows, currency, err := parseStatement(strings.NewReader(content), &model.StatementRequest{DateFormat: tt.dateFormat})
+			if err != nil {
+				t.Fatalf("parseStatement() error = %v", err)
+			}
+
+			if currency != "" {
+				t.Errorf("currency = %q, want none", currency)
+			}
+
+			checkRows(t, rows, []wantRow{
+				{cashflowModel.TypeExpense, "2026-01-05", "42.5", "Coffee Shop - Card 1234", "Food", ""},
+				{cashflowModel.TypeIncome, "2026-01-16", "1500", "Salary", "Income:Salary", ""},
+			})
+		})
+	}
+}
+
+func TestParseStatementQIFSkipsOtherSections(t *testing.T) {
+	content := "!Account\nNChecking\nTBank\n^\n" +
+		"!Type:Bank\nD2026-01-05\nT-10\nPRent\nL[Savings]\n^\n" +
+		"!Type:Invst\nD2026-01-06\nNBuy\nT100\n^\n"
+
+	rows, _, err := parseStatement(strings.NewReader(content), &model.StatementRequest{DateFormat: "YYYY-MM-DD"})
+	if err != nil {
+		t.Fatalf("parseStatement() error = %v", err)
+	}
+
+	// a bracketed category is a transfer, the row keeps no category
+	checkRows(t, rows, []wantRow{
+		{cashflowModel.TypeExpense, "2026-01-05", "10", "Rent", "", ""},
+	})
+}
+
+func TestParseStatementQIFInvalidDate(t *testing.T) {
+	content := "!Type:Bank\nD31/02/2026\nT-10\n^\n"
+
+	rows, _, err := parseStatement(strings.NewReader(content), &model.StatementRequest{DateFormat: "DD/MM/YYYY"})
+	if err != nil {
+		t.Fatalf("parseStatement() error = %v", err)
+	}
+
+	if len(rows) != 1 || len(rows[0].Errors) == 0 {
+		t.Errorf("want one row with a date error, got %+v", rows)
+	}
+}
+
+func TestParseStatementUnknownFormat(t *testing.T) {
+	_, _, err := parseStatement(strings.NewReader("date,amount\n2026-01-05,10\n"), &model.StatementRequest{})
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("parseStatement() error = %v, want %v", err, ErrUnknownFormat)
+	}
+}
+
+func TestParseLooseDate(t *testing.T) {
+	tests := []struct {
+		value      string
+		dateFormat string
+		want       string
+	}{
+		{"1/5'26", "MM/DD/YYYY", "2026-01-05"},
+		{"1/5'69", "MM/DD/YYYY", "2069-01-05"},
+		{"1/5'70", "MM/DD/YYYY", "1970-01-05"},
+		{"12/31'98", "MM/DD/YYYY", "1998-12-31"},
+		{"31.12.98", "DD.MM.YYYY", "1998-12-31"},
+		{"1998-12-31", "YYYY-MM-DD", "1998-12-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseLooseDate(tt.value, tt.dateFormat)
+			if err != nil {
+				t.Fatalf("parseLooseDate(%q) error = %v", tt.value, err)
+			}
+
+			if got.Format("2006-01-02") != tt.want {
+				t.Errorf("parseLooseDate(%q) = %s, want %s", tt.value, got.Format("2006-01-02"), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,387 @@
+package importer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/module/cashflow/expense"
+	expenseModel "github.com/fazriegi/money_management-be/module/cashflow/expense/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/income"
+	incomeModel "github.com/fazriegi/money_management-be/module/cashflow/income/model"
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/rule"
+	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/importer/model"
+	"github.com/fazriegi/money_management-be/module/master/account"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type Usecase interface {
+	PreviewCSV(user *userModel.User, file io.Reader, mapping *model.CSVMapping) (resp common.Response)
+	ImportCSV(user *userModel.User, file io.Reader, mapping *model.CSVMapping) (resp common.Response)
+	PreviewStatement(user *userModel.User, file io.Reader, req *model.StatementRequest) (resp common.Response)
+	ImportStatement(user *userModel.User, file io.Reader, req *model.StatementRequest) (resp common.Response)
+}
+
+type usecase struct {
+	log            *logrus.Logger
+	incomeRepo     income.Repository
+	expenseRepo    expense.Repository
+	accountRepo    account.Repository
+	currencyRepo   currency.Repository
+	incomeUsecase  income.Usecase
+	expenseUsecase expense.Usecase
+	matcher        rule.Matcher
+}
+
+func NewUsecase(log *logrus.Logger, incomeRepo income.Repository, expenseRepo expense.Repository, accountRepo account.Repository, currencyRepo currency.Repository, incomeUsecase income.Usecase, expenseUsecase expense.Usecase, matcher rule.Matcher) Usecase {
+	return &usecase{
+		log,
+		incomeRepo,
+		expenseRepo,
+		accountRepo,
+		currencyRepo,
+		incomeUsecase,
+		expenseUsecase,
+		matcher,
+	}
+}
+
+// PreviewCSV parses the file without storing anything, so the mapping can be
+// checked against the rows it produces
+func (u *usecase) PreviewCSV(user *userModel.User, file io.Reader, mapping *model.CSVMapping) (resp common.Response) {
+	rows, err := parseCSV(file, mapping)
+	if err != nil {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	preview, err := u.preview(user, rows)
+	if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", preview)
+}
+
+func (u *usecase) ImportCSV(user *userModel.User, file io.Reader, mapping *model.CSVMapping) (resp common.Response) {
+	rows, err := parseCSV(file, mapping)
+	if err != nil {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	return u.importRows(user, rows, mapping.AccountId, mapping.Currency)
+}
+
+// PreviewStatement parses an OFX or QIF statement without storing anything,
+// entries imported before are marked as duplicates
+func (u *usecase) PreviewStatement(user *userModel.User, file io.Reader, req *model.StatementRequest) (resp common.Response) {
+	rows, _, err := parseStatement(file, req)
+	if err != nil {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	preview, err := u.preview(user, rows)
+	if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", preview)
+}
+
+// ImportStatement stores the entries of an OFX or QIF statement that were not
+// imported before. The currency declared by an OFX statement is used when
+// the request does not set one.
+func (u *usecase) ImportStatement(user *userModel.User, file io.Reader, req *model.StatementRequest) (resp common.Response) {
+	rows, currencyCode, err := parseStatement(file, req)
+	if err != nil {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	if req.Currency != "" {
+		currencyCode = req.Currency
+	}
+
+	return u.importRows(user, rows, req.AccountId, currencyCode)
+}
+
+// importRows stores the rows as incomes and expenses in one transaction,
+// creating the categories they name that do not exist yet. Nothing is
+// stored while any row is invalid, duplicate rows are skipped.
+func (u *usecase) importRows(user *userModel.User, rows []model.Row, accountId *uint, currencyCode string) (resp common.Response) {
+	db := config.GetDatabase()
+
+	preview, err := u.preview(user, rows)
+	if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if preview.Invalid > 0 {
+		return resp.CustomResponse(http.StatusBadRequest, "invalid rows", preview)
+	}
+
+	if preview.Valid == 0 && preview.Duplicates == 0 {
+		return resp.CustomResponse(http.StatusBadRequest, "file has no transactions", nil)
+	}
+
+	if accountId != nil {
+		_, err := u.accountRepo.GetById(user.ID, *accountId, db)
+		if err != nil && errors.Is(err, sql.ErrNoRows) {
+			return resp.CustomResponse(http.StatusNotFound, "account not found", nil)
+		} else if err != nil {
+			u.log.Errorf("accountRepo.GetById: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	}
+
+	if currencyCode == "" {
+		currencyCode, err = u.currencyRepo.GetBaseCurrency(user.ID, db)
+		if err != nil {
+			u.log.Errorf("currencyRepo.GetBaseCurrency: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	createdIds, err := u.createCategories(user.ID, preview.NewCategories, tx)
+	if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	for _, row := range preview.Rows {
+		if row.Duplicate {
+			continue
+		}
+
+		var externalId *string
+		if row.ExternalId != "" {
+			externalId = &row.ExternalId
+		}
+
+		categoryId := createdIds[categoryKey(row.Type, row.Category)]
+		if row.CategoryId != nil {
+			categoryId = *row.CategoryId
+		}
+
+		if row.Type == cashflowModel.TypeIncome {
+			err = u.incomeUsecase.AddTx(user, &incomeModel.AddRequest{
+				CategoryId: categoryId,
+				Date:       row.Date,
+				Value:      row.Value,
+				Notes:      row.Notes,
+				AccountId:  accountId,
+				Currency:   currencyCode,
+				ExternalId: externalId,
+			}, tx)
+		} else {
+			err = u.expenseUsecase.AddTx(user, &expenseModel.AddRequest{
+				CategoryId: categoryId,
+				Date:       row.Date,
+				Value:      row.Value,
+				Notes:      row.Notes,
+				AccountId:  accountId,
+				Currency:   currencyCode,
+				ExternalId: externalId,
+			}, tx)
+		}
+
+		if err != nil {
+			u.log.Errorf("failed insert %s on line %d: %s", row.Type, row.Line, err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	result := map[string]any{
+		"total":          preview.Valid,
+		"duplicates":     preview.Duplicates,
+		"new_categories": preview.NewCategories,
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", result)
+}
+
+// preview matches the category of every valid row with the categories of the
+// user by name, ignoring case. Names without a match are listed as new, rows
+// without a category take the one of the first matching rule. Rows
+// whose external id was imported before or repeats within the file are
+// duplicates and are neither counted as valid nor imported.
+func (u *usecase) preview(user *userModel.User, rows []model.Row) (result model.Preview, err error) {
+	categoryIds, err := u.categoryIds(user.ID)
+	if err != nil {
+		return result, err
+	}
+
+	importedIds, err := u.importedIds(user.ID, rows)
+	if err != nil {
+		return result, err
+	}
+
+	rules, err := u.matcher.Load(user.ID, config.GetDatabase())
+	if err != nil {
+		return result, fmt.Errorf("matcher.Load: %w", err)
+	}
+
+	result = model.Preview{
+		Rows:          rows,
+		Total:         len(rows),
+		NewCategories: make([]model.NewCategory, 0),
+	}
+
+	newCategories := make(map[string]struct{})
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		if len(row.Errors) > 0 {
+			result.Invalid++
+			continue
+		}
+
+		if row.ExternalId != "" {
+			if _, ok := importedIds[row.ExternalId]; ok {
+				row.Duplicate = true
+				result.Duplicates++
+				continue
+			}
+
+			importedIds[row.ExternalId] = struct{}{}
+		}
+
+		result.Valid++
+
+		if row.Category == "" {
+			if categoryId, category, ok := rules.Match(row.Type, row.Value, row.Notes); ok {
+				row.CategoryId = &categoryId
+				row.Category = category
+				continue
+			}
+
+			row.Category = model.UncategorizedName
+		}
+
+		key := categoryKey(row.Type, row.Category)
+		if id, ok := categoryIds[key]; ok {
+			row.CategoryId = &id
+			continue
+		}
+
+		if _, ok := newCategories[key]; !ok {
+			newCategories[key] = struct{}{}
+			result.NewCategories = append(result.NewCategories, model.NewCategory{Type: row.Type, Name: row.Category})
+		}
+	}
+
+	return
+}
+
+// importedIds returns the external ids of the rows that are already stored
+// as an income or an expense
+func (u *usecase) importedIds(userId uint, rows []model.Row) (map[string]struct{}, error) {
+	db := config.GetDatabase()
+
+	externalIds := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.ExternalId != "" {
+			externalIds = append(externalIds, row.ExternalId)
+		}
+	}
+
+	incomeIds, err := u.incomeRepo.ListExternalId(userId, externalIds, db)
+	if err != nil {
+		return nil, fmt.Errorf("incomeRepo.ListExternalId: %w", err)
+	}
+
+	expenseIds, err := u.expenseRepo.ListExternalId(userId, externalIds, db)
+	if err != nil {
+		return nil, fmt.Errorf("expenseRepo.ListExternalId: %w", err)
+	}
+
+	result := make(map[string]struct{}, len(incomeIds)+len(expenseIds))
+	for _, id := range append(incomeIds, expenseIds...) {
+		result[id] = struct{}{}
+	}
+
+	return result, nil
+}
+
+// categoryIds returns the ids of the categories of the user keyed by
+// categoryKey, the first category wins when names repeat
+func (u *usecase) categoryIds(userId uint) (map[string]uint, error) {
+	db := config.GetDatabase()
+
+	incomeCategories, err := u.incomeRepo.ListCategory(userId, db)
+	if err != nil {
+		return nil, fmt.Errorf("incomeRepo.ListCategory: %w", err)
+	}
+
+	expenseCategories, err := u.expenseRepo.ListCategory(userId, db)
+	if err != nil {
+		return nil, fmt.Errorf("expenseRepo.ListCategory: %w", err)
+	}
+
+	result := make(map[string]uint, len(incomeCategories)+len(expenseCategories))
+	for _, category := range incomeCategories {
+		key := categoryKey(cashflowModel.TypeIncome, category.Name)
+		if _, ok := result[key]; !ok {
+			result[key] = category.ID
+		}
+	}
+
+	for _, category := range expenseCategories {
+		key := categoryKey(cashflowModel.TypeExpense, category.Name)
+		if _, ok := result[key]; !ok {
+			result[key] = category.ID
+		}
+	}
+
+	return result, nil
+}
+
+func (u *usecase) createCategories(userId uint, categories []model.NewCategory, tx *sqlx.Tx) (map[string]uint, error) {
+	result := make(map[string]uint, len(categories))
+	for _, category := range categories {
+		if category.Type == cashflowModel.TypeIncome {
+			data := incomeModel.IncomeCategory{Name: category.Name, UserId: userId}
+			if err := u.incomeRepo.InsertCategory(&data, tx); err != nil {
+				return nil, fmt.Errorf("failed insert income category: %w", err)
+			}
+
+			result[categoryKey(category.Type, category.Name)] = data.ID
+			continue
+		}
+
+		data := expenseModel.ExpenseCategory{Name: category.Name, UserId: userId}
+		if err := u.expenseRepo.InsertCategory(&data, tx); err != nil {
+			return nil, fmt.Errorf("failed insert expense category: %w", err)
+		}
+
+		result[categoryKey(category.Type, category.Name)] = data.ID
+	}
+
+	return result, nil
+}
+
+func categoryKey(categoryType, name string) string {
+	return categoryType + "/" + strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,16 @@
+package module
+
+import (
+	"time"
+
+	"github.com/fazriegi/money_management-be/libs"
+	balancesheet "github.com/fazriegi/money_management-be/module/balance_sheet"
+	"github.com/fazriegi/money_management-be/module/cashflow"
+)
+
+func NewJob() {
+	go libs.RunEvery(time.Hour, libs.RewrapDataKeys)
+
+	balancesheet.NewJob()
+	cashflow.NewJob()
+}
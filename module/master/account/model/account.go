@@ -0,0 +1,54 @@
+package model
+
+import "github.com/fazriegi/money_management-be/libs"
+
+const (
+	TypeCash       = "cash"
+	TypeBank       = "bank"
+	TypeEWallet    = "e-wallet"
+	TypeCreditCard = "credit_card"
+
+	TransactionIncome      = "income"
+	TransactionExpense     = "expense"
+	TransactionTransferIn  = "transfer_in"
+	TransactionTransferOut = "transfer_out"
+)
+
+type Account struct {
+	ID             uint   `db:"id"`
+	Name           string `db:"name"`
+	Type           string `db:"type"`
+	OpeningBalance string `db:"opening_balance"`
+	UserId         uint   `db:"user_id"`
+}
+
+// Transaction is a single income, expense or transfer side booked on an account
+type Transaction struct {
+	AccountId uint        `db:"account_id"`
+	Type      string      `db:"type"`
+	Value     string      `db:"value"`
+	Currency  *string     `db:"currency"`
+	Date      interface{} `db:"date"`
+}
+
+type AccountData struct {
+	ID             uint       `json:"id"`
+	Name           string     `json:"name"`
+	Type           string     `json:"type"`
+	OpeningBalance libs.Money `json:"opening_balance"`
+	Balance        libs.Money `json:"balance"`
+	Currency       string     `json:"currency"`
+}
+
+type AddRequest struct {
+	Name           string     `json:"name" validate:"required,max=50"`
+	Type           string     `json:"type" validate:"required,oneof=cash bank e-wallet credit_card"`
+	OpeningBalance libs.Money `json:"opening_balance"`
+}
+
+type UpdateRequest struct {
+	ID             uint
+	Name           string     `json:"name" validate:"required,max=50"`
+	Type           string     `json:"type" validate:"required,oneof=cash bank e-wallet credit_card"`
+	OpeningBalance libs.Money `json:"opening_balance"`
+}
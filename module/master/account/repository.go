@@ -0,0 +1,226 @@
+package account
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/master/account/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Insert(data *model.Account, tx *sqlx.Tx) error
+	List(userId uint, db *sqlx.DB) (result []model.Account, err error)
+	GetById(userId, id uint, db *sqlx.DB) (result model.Account, err error)
+	Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error
+	Delete(userId, id uint, tx *sqlx.Tx) error
+	CountTransaction(userId, id uint, tx *sqlx.Tx) (total uint, err error)
+	ListTransaction(userId uint, db *sqlx.DB) (result []model.Transaction, err error)
+}
+
+type repository struct{}
+
+func NewRepository() Repository {
+	return &repository{}
+}
+
+func (r *repository) Insert(data *model.Account, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("account").Rows(*data)
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) List(userId uint, db *sqlx.DB) (result []model.Account, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("account").
+		Where(goqu.I("user_id").Eq(userId)).
+		Order(goqu.I("name").Asc())
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.Account, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) GetById(userId, id uint, db *sqlx.DB) (result model.Account, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("account").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("account").
+		Set(data).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(userId, id uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Delete("account").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	return nil
+}
+
+// CountTransaction counts the incomes, expenses and transfer sides booked on
+// the account
+func (r *repository) CountTransaction(userId, id uint, tx *sqlx.Tx) (total uint, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From(transactionQuery(userId).As("trx")).
+		Select(goqu.COUNT("*")).
+		Where(goqu.I("account_id").Eq(id))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = tx.Get(&total, sql, val...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) ListTransaction(userId uint, db *sqlx.DB) (result []model.Transaction, err error) {
+	sql, val, err := transactionQuery(userId).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.Transaction, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+// transactionQuery selects every income, expense and transfer side of the
+// user that is booked on an account. Transfers have no currency of their own,
+// they are in the base currency.
+func transactionQuery(userId uint) *goqu.SelectDataset {
+	dialect := libs.GetDialect()
+
+	incomeDataset := dialect.From("income").
+		Select(
+			goqu.I("account_id"),
+			goqu.V(model.TransactionIncome).As("type"),
+			goqu.I("value"),
+			goqu.I("currency"),
+			goqu.I("date"),
+		).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("account_id").IsNotNull(),
+		)
+
+	expenseDataset := dialect.From("expense").
+		Select(
+			goqu.I("account_id"),
+			goqu.V(model.TransactionExpense).As("type"),
+			goqu.I("value"),
+			goqu.I("currency"),
+			goqu.I("date"),
+		).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("account_id").IsNotNull(),
+		)
+
+	transferOutDataset := dialect.From("transfer").
+		Select(
+			goqu.I("from_account_id").As("account_id"),
+			goqu.V(model.TransactionTransferOut).As("type"),
+			goqu.I("value"),
+			goqu.L("NULL").As("currency"),
+			goqu.I("date"),
+		).
+		Where(goqu.I("user_id").Eq(userId))
+
+	transferInDataset := dialect.From("transfer").
+		Select(
+			goqu.I("to_account_id").As("account_id"),
+			goqu.V(model.TransactionTransferIn).As("type"),
+			goqu.I("value"),
+			goqu.L("NULL").As("currency"),
+			goqu.I("date"),
+		).
+		Where(goqu.I("user_id").Eq(userId))
+
+	return incomeDataset.
+		UnionAll(expenseDataset).
+		UnionAll(transferOutDataset).
+		UnionAll(transferInDataset)
+}
@@ -0,0 +1,238 @@
+package account
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/account/model"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/sirupsen/logrus"
+)
+
+type Usecase interface {
+	Add(user *userModel.User, req *model.AddRequest) (resp common.Response)
+	List(user *userModel.User) (resp common.Response)
+	Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response)
+	Delete(user *userModel.User, id uint) (resp common.Response)
+}
+
+type usecase struct {
+	log          *logrus.Logger
+	repo         Repository
+	currencyRepo currency.Repository
+}
+
+func NewUsecase(log *logrus.Logger, repo Repository, currencyRepo currency.Repository) Usecase {
+	return &usecase{
+		log,
+		repo,
+		currencyRepo,
+	}
+}
+
+func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.OpeningBalance.String())
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	data := model.Account{
+		Name:           req.Name,
+		Type:           req.Type,
+		OpeningBalance: encValue,
+		UserId:         user.ID,
+	}
+
+	err = u.repo.Insert(&data, tx)
+	if err != nil {
+		u.log.Errorf("failed insert account: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", nil)
+}
+
+// List returns the accounts with their balance in the base currency, every
+// movement is converted at the rate of its date before it is summed
+func (u *usecase) List(user *userModel.User) (resp common.Response) {
+	db := config.GetDatabase()
+	key := fmt.Sprintf("%d", user.ID)
+
+	accounts, err := u.repo.List(user.ID, db)
+	if err != nil {
+		u.log.Errorf("repo.List: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	transactions, err := u.repo.ListTransaction(user.ID, db)
+	if err != nil {
+		u.log.Errorf("repo.ListTransaction: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	converter, err := currency.NewConverter(user.ID, u.currencyRepo, db)
+	if err != nil {
+		u.log.Errorf("currency.NewConverter: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	movements := make(map[uint]libs.Money)
+	for _, data := range transactions {
+		value, err := decryptMoney(key, data.Value)
+		if err != nil {
+			u.log.Errorf("decryptMoney: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		var transactionCurrency string
+		if data.Currency != nil {
+			transactionCurrency = *data.Currency
+		}
+
+		value, err = converter.Convert(value, transactionCurrency, libs.ParseDate(data.Date))
+		if err != nil && errors.Is(err, currency.ErrRateNotFound) {
+			return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+		} else if err != nil {
+			u.log.Errorf("converter.Convert: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		if data.Type == model.TransactionExpense || data.Type == model.TransactionTransferOut {
+			value = value.Neg()
+		}
+
+		movements[data.AccountId] = movements[data.AccountId].Add(value)
+	}
+
+	result := make([]model.AccountData, len(accounts))
+	for i, data := range accounts {
+		openingBalance, err := decryptMoney(key, data.OpeningBalance)
+		if err != nil {
+			u.log.Errorf("decryptMoney: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		result[i] = model.AccountData{
+			ID:             data.ID,
+			Name:           data.Name,
+			Type:           data.Type,
+			OpeningBalance: openingBalance,
+			Balance:        openingBalance.Add(movements[data.ID]),
+			Currency:       converter.Base(),
+		}
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	encValue, err := libs.Encrypt(fmt.Sprintf("%d", user.ID), req.OpeningBalance.String())
+	if err != nil {
+		u.log.Errorf("error encrypting value: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	data := map[string]any{
+		"name":            req.Name,
+		"type":            req.Type,
+		"opening_balance": encValue,
+	}
+
+	err = u.repo.Update(user.ID, req.ID, data, tx)
+	if err != nil {
+		u.log.Errorf("failed update account: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) Delete(user *userModel.User, id uint) (resp common.Response) {
+	db := config.GetDatabase()
+
+	_, err := u.repo.GetById(user.ID, id, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "account not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetById: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	total, err := u.repo.CountTransaction(user.ID, id, tx)
+	if err != nil {
+		u.log.Errorf("repo.CountTransaction: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if total > 0 {
+		return resp.CustomResponse(http.StatusBadRequest, fmt.Sprintf("account is still used by %d transaction(s)", total), nil)
+	}
+
+	err = u.repo.Delete(user.ID, id, tx)
+	if err != nil {
+		u.log.Errorf("failed delete account: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func decryptMoney(key, cipher string) (libs.Money, error) {
+	decValue, err := libs.Decrypt(key, cipher)
+	if err != nil {
+		return libs.Money{}, fmt.Errorf("error decrypting value: %w", err)
+	}
+
+	value, err := libs.ParseMoney(decValue)
+	if err != nil {
+		return libs.Money{}, fmt.Errorf("error parsing string: %w", err)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,95 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/master/currency/model"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// Converter turns amounts into the user's base currency. It loads every rate
+// of the user once, so it is meant to live for a single request or job run.
+type Converter struct {
+	base  string
+	rates map[string][]model.GetExchangeRate
+}
+
+func NewConverter(userId uint, repo Repository, db *sqlx.DB) (*Converter, error) {
+	base, err := repo.GetBaseCurrency(userId, db)
+	if err != nil {
+		return nil, fmt.Errorf("repo.GetBaseCurrency: %w", err)
+	}
+
+	rates, err := repo.ListRate(&model.RateListRequest{UserId: userId}, db)
+	if err != nil {
+		return nil, fmt.Errorf("repo.ListRate: %w", err)
+	}
+
+	c := &Converter{
+		base:  base,
+		rates: make(map[string][]model.GetExchangeRate),
+	}
+	for _, rate := range rates {
+		key := pairKey(rate.FromCurrency, rate.ToCurrency)
+		c.rates[key] = append(c.rates[key], rate)
+	}
+	for _, list := range c.rates {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Date.Before(list[j].Date)
+		})
+	}
+
+	return c, nil
+}
+
+func (c *Converter) Base() string {
+	return c.base
+}
+
+// Convert returns the value in the base currency at the rate of the given
+// date. The latest rate on or before the date is used, and the inverse pair
+// when the direct pair has none. A rate set after the date is never used.
+func (c *Converter) Convert(value libs.Money, currency string, date time.Time) (libs.Money, error) {
+	if currency == "" || currency == c.base {
+		return value, nil
+	}
+
+	if rate, ok := c.rateAt(currency, c.base, date); ok {
+		return value.Mul(rate), nil
+	}
+
+	if rate, ok := c.rateAt(c.base, currency, date); ok {
+		return value.Div(rate), nil
+	}
+
+	return libs.Money{}, fmt.Errorf("%w: %s to %s on or before %s", ErrRateNotFound, currency, c.base, date.Format(constant.DateFormat))
+}
+
+func (c *Converter) rateAt(from, to string, date time.Time) (libs.Money, bool) {
+	list := c.rates[pairKey(from, to)]
+	if len(list) == 0 {
+		return libs.Money{}, false
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	idx := sort.Search(len(list), func(i int) bool {
+		rateDay := list[i].Date
+		return time.Date(rateDay.Year(), rateDay.Month(), rateDay.Day(), 0, 0, 0, 0, time.UTC).After(day)
+	})
+	if idx == 0 {
+		return libs.Money{}, false
+	}
+
+	return list[idx-1].Rate, true
+}
+
+func pairKey(from, to string) string {
+	return from + "/" + to
+}
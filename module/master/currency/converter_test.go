@@ -0,0 +1,128 @@
+package currency
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/master/currency/model"
+	"github.com/jmoiron/sqlx"
+)
+
+// fakeRepository serves a fixed base currency and rates to NewConverter
+type fakeRepository struct {
+	Repository
+	base  string
+	rates []model.GetExchangeRate
+}
+
+func (r *fakeRepository) GetBaseCurrency(userId uint, db *sqlx.DB) (string, error) {
+	return r.base, nil
+}
+
+func (r *fakeRepository) ListRate(req *model.RateListRequest, db *sqlx.DB) ([]model.GetExchangeRate, error) {
+	return r.rates, nil
+}
+
+func rate(from, to, date, value string) model.GetExchangeRate {
+	// rates are scanned from a DATE column, which the driver gives in UTC
+	parsed, err := time.Parse(constant.DateFormat, date)
+	if err != nil {
+		panic(err)
+	}
+
+	return model.GetExchangeRate{FromCurrency: from, ToCurrency: to, Date: parsed, Rate: libs.MustParseMoney(value)}
+}
+
+func TestConvert(t *testing.T) {
+	repo := &fakeRepository{
+		base: "IDR",
+		// out of order on purpose, NewConverter sorts them by date
+		rates: []model.GetExchangeRate{
+			rate("USD", "IDR", "2026-02-01", "16000"),
+			rate("USD", "IDR", "2026-01-01", "15000"),
+			rate("IDR", "EUR", "2026-01-10", "0.00005"),
+		},
+	}
+
+	converter, err := NewConverter(1, repo, nil)
+	if err != nil {
+		t.Fatalf("NewConverter() error = %v", err)
+	}
+
+	if converter.Base() != "IDR" {
+		t.Errorf("Base() = %s, want IDR", converter.Base())
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+		date     string
+		want     string
+		wantErr  error
+	}{
+		{"base currency is kept", "10", "IDR", "2020-01-01", "10", nil},
+		{"no currency is the base currency", "10", "", "2020-01-01", "10", nil},
+		{"rate between two dates", "2", "USD", "2026-01-15", "30000", nil},
+		{"rate set on the date", "2", "USD", "2026-02-01", "32000", nil},
+		{"day before a new rate", "2", "USD", "2026-01-31", "30000", nil},
+		{"latest rate", "2", "USD", "2027-06-01", "32000", nil},
+		{"only later rates", "2", "USD", "2025-12-31", "", ErrRateNotFound},
+		{"inverse pair", "3", "EUR", "2026-01-10", "60000", nil},
+		{"inverse pair before its first rate", "3", "EUR", "2026-01-09", "", ErrRateNotFound},
+		{"unknown currency", "1", "JPY", "2026-01-10", "", ErrRateNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// transaction dates are read in the server's time zone
+			date, err := time.ParseInLocation(constant.DateFormat, tt.date, time.Local)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := converter.Convert(libs.MustParseMoney(tt.value), tt.currency, date)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Convert() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("Convert(%s %s, %s) = %s, want %s", tt.value, tt.currency, tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertPrefersDirectPair(t *testing.T) {
+	repo := &fakeRepository{
+		base: "IDR",
+		rates: []model.GetExchangeRate{
+			rate("USD", "IDR", "2026-01-01", "15000"),
+			rate("IDR", "USD", "2026-01-01", "0.00005"),
+		},
+	}
+
+	converter, err := NewConverter(1, repo, nil)
+	if err != nil {
+		t.Fatalf("NewConverter() error = %v", err)
+	}
+
+	got, err := converter.Convert(libs.MustParseMoney("1"), "USD", time.Date(2026, 1, 5, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	if got.String() != "15000" {
+		t.Errorf("Convert() = %s, want the direct rate 15000", got)
+	}
+}
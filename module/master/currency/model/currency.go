@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/fazriegi/money_management-be/libs"
+)
+
+type Currency struct {
+	BaseCurrency string `db:"base_currency" json:"base_currency"`
+}
+
+type UpdateRequest struct {
+	BaseCurrency string `json:"base_currency" validate:"required,iso4217"`
+}
+
+type ExchangeRate struct {
+	ID           uint       `db:"id"`
+	FromCurrency string     `db:"from_currency"`
+	ToCurrency   string     `db:"to_currency"`
+	Date         string     `db:"date"`
+	Rate         libs.Money `db:"rate"`
+	UserId       uint       `db:"user_id"`
+}
+
+type GetExchangeRate struct {
+	ID           uint       `db:"id" json:"id"`
+	FromCurrency string     `db:"from_currency" json:"from_currency"`
+	ToCurrency   string     `db:"to_currency" json:"to_currency"`
+	Date         time.Time  `db:"date" json:"date"`
+	Rate         libs.Money `db:"rate" json:"rate"`
+	UserId       uint       `db:"user_id" json:"-"`
+}
+
+type RateRequest struct {
+	ID           uint
+	FromCurrency string     `json:"from_currency" validate:"required,iso4217"`
+	ToCurrency   string     `json:"to_currency" validate:"omitempty,iso4217,nefield=FromCurrency"`
+	Date         string     `json:"date" validate:"required,datetime=2006-01-02"`
+	Rate         libs.Money `json:"rate" validate:"required,gt=0"`
+}
+
+type RateListRequest struct {
+	Currency  string `query:"currency"`
+	StartDate string `query:"start_date"`
+	EndDate   string `query:"end_date"`
+	UserId    uint
+}
+
+type RateRowError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,165 @@
+package currency
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/master/currency/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetBaseCurrency(userId uint, db *sqlx.DB) (result string, err error)
+	UpdateBaseCurrency(userId uint, currency string, tx *sqlx.Tx) error
+	UpsertRate(data []model.ExchangeRate, tx *sqlx.Tx) error
+	ListRate(req *model.RateListRequest, db *sqlx.DB) (result []model.GetExchangeRate, err error)
+	UpdateRate(userId, id uint, data map[string]any, tx *sqlx.Tx) error
+	DeleteRate(userId, id uint, tx *sqlx.Tx) error
+}
+
+type repository struct{}
+
+func NewRepository() Repository {
+	return &repository{}
+}
+
+func (r *repository) GetBaseCurrency(userId uint, db *sqlx.DB) (result string, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("user").
+		Select(goqu.I("base_currency")).
+		Where(goqu.I("id").Eq(userId))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) UpdateBaseCurrency(userId uint, currency string, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("user").
+		Set(goqu.Record{"base_currency": currency}).
+		Where(goqu.I("id").Eq(userId))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertRate inserts the rates, replacing the rate of a pair that already
+// has one on the same date
+func (r *repository) UpsertRate(data []model.ExchangeRate, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("exchange_rate").
+		Rows(data).
+		OnConflict(goqu.DoUpdate("rate", goqu.Record{"rate": goqu.L("VALUES(rate)")}))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) ListRate(req *model.RateListRequest, db *sqlx.DB) (result []model.GetExchangeRate, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("exchange_rate").
+		Where(goqu.I("user_id").Eq(req.UserId)).
+		Order(goqu.I("date").Desc(), goqu.I("from_currency").Asc())
+
+	if req.Currency != "" {
+		dataset = dataset.Where(goqu.Or(
+			goqu.I("from_currency").Eq(req.Currency),
+			goqu.I("to_currency").Eq(req.Currency),
+		))
+	}
+
+	if req.StartDate != "" && req.EndDate != "" {
+		dataset = dataset.Where(goqu.I("date").Between(exp.NewRangeVal(req.StartDate, req.EndDate)))
+	}
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.GetExchangeRate, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) UpdateRate(userId, id uint, data map[string]any, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("exchange_rate").
+		Set(data).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteRate(userId, id uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Delete("exchange_rate").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	return nil
+}
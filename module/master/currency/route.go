@@ -0,0 +1,25 @@
+package currency
+
+import (
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func NewRoute(app *fiber.App, jwt *libs.JWT) {
+	log := config.GetLogger()
+
+	repo := NewRepository()
+	usecase := NewUsecase(log, repo)
+	controller := NewController(log, usecase)
+
+	route := app.Group("/currency")
+	route.Get("/", middleware.Authentication(jwt), controller.Get)
+	route.Put("/", middleware.Authentication(jwt), controller.Update)
+	route.Get("/rate", middleware.Authentication(jwt), controller.ListRate)
+	route.Post("/rate", middleware.Authentication(jwt), controller.AddRate)
+	route.Post("/rate/upload", middleware.Authentication(jwt), controller.UploadRate)
+	route.Put("/rate/:id", middleware.Authentication(jwt), controller.UpdateRate)
+	route.Delete("/rate/:id", middleware.Authentication(jwt), controller.DeleteRate)
+}
@@ -0,0 +1,336 @@
+package currency
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/currency/model"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/sirupsen/logrus"
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type Usecase interface {
+	Get(user *userModel.User) (resp common.Response)
+	Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response)
+	ListRate(req *model.RateListRequest) (resp common.Response)
+	AddRate(user *userModel.User, req *model.RateRequest) (resp common.Response)
+	UpdateRate(user *userModel.User, req *model.RateRequest) (resp common.Response)
+	DeleteRate(user *userModel.User, id uint) (resp common.Response)
+	UploadRate(user *userModel.User, file io.Reader) (resp common.Response)
+}
+
+type usecase struct {
+	log  *logrus.Logger
+	repo Repository
+}
+
+func NewUsecase(log *logrus.Logger, repo Repository) Usecase {
+	return &usecase{
+		log,
+		repo,
+	}
+}
+
+func (u *usecase) Get(user *userModel.User) (resp common.Response) {
+	db := config.GetDatabase()
+
+	base, err := u.repo.GetBaseCurrency(user.ID, db)
+	if err != nil {
+		u.log.Errorf("repo.GetBaseCurrency: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", model.Currency{BaseCurrency: base})
+}
+
+func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	err = u.repo.UpdateBaseCurrency(user.ID, req.BaseCurrency, tx)
+	if err != nil {
+		u.log.Errorf("repo.UpdateBaseCurrency: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) ListRate(req *model.RateListRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	result, err := u.repo.ListRate(req, db)
+	if err != nil {
+		u.log.Errorf("repo.ListRate: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+func (u *usecase) AddRate(user *userModel.User, req *model.RateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	if req.ToCurrency == "" {
+		base, err := u.repo.GetBaseCurrency(user.ID, db)
+		if err != nil {
+			u.log.Errorf("repo.GetBaseCurrency: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		if req.FromCurrency == base {
+			return resp.CustomResponse(http.StatusBadRequest, "from_currency is already the base currency", nil)
+		}
+		req.ToCurrency = base
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	data := []model.ExchangeRate{{
+		FromCurrency: req.FromCurrency,
+		ToCurrency:   req.ToCurrency,
+		Date:         req.Date,
+		Rate:         req.Rate,
+		UserId:       user.ID,
+	}}
+
+	err = u.repo.UpsertRate(data, tx)
+	if err != nil {
+		u.log.Errorf("repo.UpsertRate: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", nil)
+}
+
+func (u *usecase) UpdateRate(user *userModel.User, req *model.RateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	if req.ToCurrency == "" {
+		base, err := u.repo.GetBaseCurrency(user.ID, db)
+		if err != nil {
+			u.log.Errorf("repo.GetBaseCurrency: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		if req.FromCurrency == base {
+			return resp.CustomResponse(http.StatusBadRequest, "from_currency is already the base currency", nil)
+		}
+		req.ToCurrency = base
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	data := map[string]any{
+		"from_currency": req.FromCurrency,
+		"to_currency":   req.ToCurrency,
+		"date":          req.Date,
+		"rate":          req.Rate,
+	}
+
+	err = u.repo.UpdateRate(user.ID, req.ID, data, tx)
+	if err != nil {
+		u.log.Errorf("repo.UpdateRate: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) DeleteRate(user *userModel.User, id uint) (resp common.Response) {
+	db := config.GetDatabase()
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	err = u.repo.DeleteRate(user.ID, id, tx)
+	if err != nil {
+		u.log.Errorf("repo.DeleteRate: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+// UploadRate reads a CSV with the header from_currency,to_currency,date,rate.
+// The to_currency column is optional and defaults to the base currency. All
+// rows are saved in one transaction, or none when any row is invalid.
+func (u *usecase) UploadRate(user *userModel.User, file io.Reader) (resp common.Response) {
+	db := config.GetDatabase()
+
+	base, err := u.repo.GetBaseCurrency(user.ID, db)
+	if err != nil {
+		u.log.Errorf("repo.GetBaseCurrency: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	data, rowErrs, err := parseRateCSV(file, base, user.ID)
+	if err != nil {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	}
+
+	if len(rowErrs) > 0 {
+		return resp.CustomResponse(http.StatusBadRequest, "invalid rows", map[string]any{"errors": rowErrs})
+	}
+
+	if len(data) == 0 {
+		return resp.CustomResponse(http.StatusBadRequest, "file has no rates", nil)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	err = u.repo.UpsertRate(data, tx)
+	if err != nil {
+		u.log.Errorf("repo.UpsertRate: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", map[string]any{"total": len(data)})
+}
+
+func parseRateCSV(file io.Reader, base string, userId uint) ([]model.ExchangeRate, []model.RateRowError, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, errors.New("file is not a valid csv")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"from_currency", "date", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	column := func(record []string, name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[idx])
+	}
+
+	var (
+		result  []model.ExchangeRate
+		rowErrs []model.RateRowError
+		line    = 1
+	)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+
+		if err != nil {
+			rowErrs = append(rowErrs, model.RateRowError{Line: line, Message: err.Error()})
+			continue
+		}
+
+		from := strings.ToUpper(column(record, "from_currency"))
+		to := strings.ToUpper(column(record, "to_currency"))
+		if to == "" {
+			to = base
+		}
+
+		if !currencyCode.MatchString(from) || !currencyCode.MatchString(to) {
+			rowErrs = append(rowErrs, model.RateRowError{Line: line, Message: "invalid currency code"})
+			continue
+		}
+
+		if from == to {
+			rowErrs = append(rowErrs, model.RateRowError{Line: line, Message: "from_currency and to_currency must differ"})
+			continue
+		}
+
+		date := column(record, "date")
+		if _, err := time.Parse(constant.DateFormat, date); err != nil {
+			rowErrs = append(rowErrs, model.RateRowError{Line: line, Message: "date must use the format YYYY-MM-DD"})
+			continue
+		}
+
+		rate, err := libs.ParseMoney(column(record, "rate"))
+		if err != nil || rate.Sign() <= 0 {
+			rowErrs = append(rowErrs, model.RateRowError{Line: line, Message: "rate must be a positive number"})
+			continue
+		}
+
+		result = append(result, model.ExchangeRate{
+			FromCurrency: from,
+			ToCurrency:   to,
+			Date:         date,
+			Rate:         rate,
+			UserId:       userId,
+		})
+	}
+
+	return result, rowErrs, nil
+}
@@ -1,5 +1,29 @@
 package model
 
+import "time"
+
+// LastDayOfMonth is stored for users whose period starts on the last day of
+// the month, shorter months clamp it to their own last day
+const LastDayOfMonth uint8 = 31
+
 type MonthlyPeriod struct {
 	DayOfMonth uint8 `db:"day_of_month" json:"day_of_month"`
+	LastDay    bool  `db:"-" json:"last_day"`
+	UserId     uint  `db:"user_id" json:"-"`
+}
+
+// PeriodRange is a concrete monthly period, both dates are inclusive
+type PeriodRange struct {
+	Period    string    `json:"period"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+}
+
+type UpdateRequest struct {
+	DayOfMonth uint8 `json:"day_of_month" validate:"required_without=LastDay,omitempty,min=1,max=28"`
+	LastDay    bool  `json:"last_day"`
+}
+
+type RangesRequest struct {
+	Count uint `query:"count" validate:"omitempty,max=120"`
 }
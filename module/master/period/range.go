@@ -0,0 +1,106 @@
+package period
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/module/master/period/model"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	PeriodCurrent  = "current"
+	PeriodPrevious = "previous"
+)
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+// StartDay returns the day a period starts on in the given month. Months
+// shorter than dayOfMonth start their period on their last day.
+func StartDay(dayOfMonth uint8, year int, month time.Month) int {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.Local).Day()
+	if int(dayOfMonth) > lastDay {
+		return lastDay
+	}
+
+	return int(dayOfMonth)
+}
+
+// GetRange returns the period that starts in the given month
+func GetRange(dayOfMonth uint8, year int, month time.Month) model.PeriodRange {
+	// normalize overflowing months such as 0 or 13
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	year, month = first.Year(), first.Month()
+
+	start := time.Date(year, month, StartDay(dayOfMonth, year, month), 0, 0, 0, 0, time.Local)
+
+	next := time.Date(year, month+1, 1, 0, 0, 0, 0, time.Local)
+	nextStart := time.Date(next.Year(), next.Month(), StartDay(dayOfMonth, next.Year(), next.Month()), 0, 0, 0, 0, time.Local)
+
+	return model.PeriodRange{
+		Period:    start.Format("2006-01"),
+		StartDate: start,
+		EndDate:   nextStart.AddDate(0, 0, -1),
+	}
+}
+
+// GetRangeOf returns the period the given date falls in
+func GetRangeOf(dayOfMonth uint8, date time.Time) model.PeriodRange {
+	year, month := date.Year(), date.Month()
+	if date.Day() < StartDay(dayOfMonth, year, month) {
+		month--
+	}
+
+	return GetRange(dayOfMonth, year, month)
+}
+
+// Shift returns the period n months away from the given one, n may be negative
+func Shift(dayOfMonth uint8, periodRange model.PeriodRange, n int) model.PeriodRange {
+	start := periodRange.StartDate
+	first := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, time.Local)
+
+	return GetRange(dayOfMonth, first.Year(), first.Month())
+}
+
+// Resolve turns a period query value into its range. It accepts "current",
+// "previous" or a month formatted as YYYY-MM, which names the period that
+// starts in that month.
+func Resolve(dayOfMonth uint8, value string, now time.Time) (model.PeriodRange, error) {
+	switch value {
+	case PeriodCurrent:
+		return GetRangeOf(dayOfMonth, now), nil
+	case PeriodPrevious:
+		return Shift(dayOfMonth, GetRangeOf(dayOfMonth, now), -1), nil
+	}
+
+	month, err := time.ParseInLocation("2006-01", value, time.Local)
+	if err != nil {
+		return model.PeriodRange{}, ErrInvalidPeriod
+	}
+
+	return GetRange(dayOfMonth, month.Year(), month.Month()), nil
+}
+
+// ResolveRange resolves a period query value against the user's period
+// setting. The error is ErrInvalidPeriod when the value cannot be read.
+func ResolveRange(repo Repository, userId uint, value string, db *sqlx.DB) (model.PeriodRange, error) {
+	userPeriod, err := repo.GetPeriod(userId, db)
+	if err != nil {
+		return model.PeriodRange{}, fmt.Errorf("repo.GetPeriod: %w", err)
+	}
+
+	return Resolve(userPeriod.DayOfMonth, value, time.Now())
+}
+
+// ResolveDates is ResolveRange returning the first and last day of the period
+// formatted as dates, the way list filters take them
+func ResolveDates(repo Repository, userId uint, value string, db *sqlx.DB) (start, end string, err error) {
+	periodRange, err := ResolveRange(repo, userId, value, db)
+	if err != nil {
+		return "", "", err
+	}
+
+	return periodRange.StartDate.Format(constant.DateFormat), periodRange.EndDate.Format(constant.DateFormat), nil
+}
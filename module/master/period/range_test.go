@@ -0,0 +1,136 @@
+package period
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/module/master/period/model"
+)
+
+func mustDate(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	result, err := time.ParseInLocation(constant.DateFormat, value, time.Local)
+	if err != nil {
+		t.Fatalf("invalid date %q: %v", value, err)
+	}
+
+	return result
+}
+
+func checkRange(t *testing.T, got model.PeriodRange, wantPeriod, wantStart, wantEnd string) {
+	t.Helper()
+
+	start, end := got.StartDate.Format(constant.DateFormat), got.EndDate.Format(constant.DateFormat)
+	if got.Period != wantPeriod || start != wantStart || end != wantEnd {
+		t.Errorf("range = %s %s..%s, want %s %s..%s", got.Period, start, end, wantPeriod, wantStart, wantEnd)
+	}
+}
+
+func TestGetRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		dayOfMonth uint8
+		year       int
+		month      time.Month
+		period     string
+		start      string
+		end        string
+	}{
+		{"calendar month", 1, 2026, time.December, "2026-12", "2026-12-01", "2026-12-31"},
+		{"ends before a clamped start", 31, 2026, time.January, "2026-01", "2026-01-31", "2026-02-27"},
+		{"starts on the last day of february", 31, 2026, time.February, "2026-02", "2026-02-28", "2026-03-30"},
+		{"leap february", 30, 2028, time.February, "2028-02", "2028-02-29", "2028-03-29"},
+		{"thirty day month", 31, 2026, time.April, "2026-04", "2026-04-30", "2026-05-30"},
+		{"overflowing month", 25, 2026, 13, "2027-01", "2027-01-25", "2027-02-24"},
+		{"month zero", 25, 2026, 0, "2025-12", "2025-12-25", "2026-01-24"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkRange(t, GetRange(tt.dayOfMonth, tt.year, tt.month), tt.period, tt.start, tt.end)
+		})
+	}
+}
+
+func TestGetRangeOf(t *testing.T) {
+	tests := []struct {
+		dayOfMonth uint8
+		date       string
+		period     string
+		start      string
+		end        string
+	}{
+		{31, "2026-02-27", "2026-01", "2026-01-31", "2026-02-27"},
+		{31, "2026-02-28", "2026-02", "2026-02-28", "2026-03-30"},
+		{31, "2026-03-30", "2026-02", "2026-02-28", "2026-03-30"},
+		{31, "2026-03-31", "2026-03", "2026-03-31", "2026-04-29"},
+		{25, "2026-01-10", "2025-12", "2025-12-25", "2026-01-24"},
+		{25, "2026-01-25", "2026-01", "2026-01-25", "2026-02-24"},
+		{1, "2026-05-31", "2026-05", "2026-05-01", "2026-05-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			checkRange(t, GetRangeOf(tt.dayOfMonth, mustDate(t, tt.date)), tt.period, tt.start, tt.end)
+		})
+	}
+}
+
+func TestShift(t *testing.T) {
+	january := GetRange(31, 2026, time.January)
+
+	tests := []struct {
+		name   string
+		n      int
+		period string
+		start  string
+		end    string
+	}{
+		{"next into february", 1, "2026-02", "2026-02-28", "2026-03-30"},
+		{"two ahead back to the 31st", 2, "2026-03", "2026-03-31", "2026-04-29"},
+		{"previous year", -1, "2025-12", "2025-12-31", "2026-01-30"},
+		{"a year ahead", 12, "2027-01", "2027-01-31", "2027-02-27"},
+		{"same period", 0, "2026-01", "2026-01-31", "2026-02-27"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkRange(t, Shift(31, january, tt.n), tt.period, tt.start, tt.end)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	now := mustDate(t, "2026-03-05")
+
+	tests := []struct {
+		value  string
+		period string
+		start  string
+		end    string
+	}{
+		{PeriodCurrent, "2026-02", "2026-02-28", "2026-03-30"},
+		{PeriodPrevious, "2026-01", "2026-01-31", "2026-02-27"},
+		{"2026-04", "2026-04", "2026-04-30", "2026-05-30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Resolve(31, tt.value, now)
+			if err != nil {
+				t.Fatalf("Resolve(%q) error = %v", tt.value, err)
+			}
+
+			checkRange(t, got, tt.period, tt.start, tt.end)
+		})
+	}
+
+	for _, value := range []string{"", "next", "2026-13", "2026-4", "2026-04-01"} {
+		if _, err := Resolve(31, value, now); !errors.Is(err, ErrInvalidPeriod) {
+			t.Errorf("Resolve(%q) error = %v, want %v", value, err, ErrInvalidPeriod)
+		}
+	}
+}
@@ -11,6 +11,8 @@ import (
 
 type Repository interface {
 	GetPeriod(userId uint, db *sqlx.DB) (result model.MonthlyPeriod, err error)
+	ListPeriod(db *sqlx.DB) (result []model.MonthlyPeriod, err error)
+	UpdatePeriod(userId uint, dayOfMonth uint8, tx *sqlx.Tx) error
 }
 
 type repository struct {
@@ -39,3 +41,43 @@ func (r *repository) GetPeriod(userId uint, db *sqlx.DB) (result model.MonthlyPe
 
 	return
 }
+
+func (r *repository) ListPeriod(db *sqlx.DB) (result []model.MonthlyPeriod, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("monthly_period").
+		Select(goqu.I("day_of_month"), goqu.I("user_id"))
+
+	query, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.MonthlyPeriod, 0)
+	err = db.Select(&result, query, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) UpdatePeriod(userId uint, dayOfMonth uint8, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("monthly_period").
+		Set(goqu.Record{"day_of_month": dayOfMonth}).
+		Where(goqu.I("user_id").Eq(userId))
+
+	query, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(query, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
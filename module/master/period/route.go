@@ -16,4 +16,6 @@ func NewRoute(app *fiber.App, jwt *libs.JWT) {
 
 	route := app.Group("/period")
 	route.Get("/", middleware.Authentication(jwt), controller.Get)
+	route.Put("/", middleware.Authentication(jwt), controller.Update)
+	route.Get("/ranges", middleware.Authentication(jwt), controller.Ranges)
 }
@@ -2,16 +2,22 @@ package period
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/fazriegi/money_management-be/config"
 	"github.com/fazriegi/money_management-be/constant"
 	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/period/model"
 	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
 	"github.com/sirupsen/logrus"
 )
 
+const defaultRangeCount = 12
+
 type Usecase interface {
 	Get(user *userModel.User) (resp common.Response)
+	Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response)
+	Ranges(user *userModel.User, req *model.RangesRequest) (resp common.Response)
 }
 
 type usecase struct {
@@ -35,5 +41,60 @@ func (u *usecase) Get(user *userModel.User) (resp common.Response) {
 		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
 	}
 
+	result.LastDay = result.DayOfMonth == model.LastDayOfMonth
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	dayOfMonth := req.DayOfMonth
+	if req.LastDay {
+		dayOfMonth = model.LastDayOfMonth
+	}
+
+	err = u.repo.UpdatePeriod(user.ID, dayOfMonth, tx)
+	if err != nil {
+		u.log.Errorf("failed update period: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+func (u *usecase) Ranges(user *userModel.User, req *model.RangesRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	userPeriod, err := u.repo.GetPeriod(user.ID, db)
+	if err != nil {
+		u.log.Errorf("repo.GetPeriod: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	count := req.Count
+	if count == 0 {
+		count = defaultRangeCount
+	}
+
+	current := GetRangeOf(userPeriod.DayOfMonth, time.Now())
+
+	// most recent period first
+	result := make([]model.PeriodRange, count)
+	for i := range result {
+		result[i] = Shift(userPeriod.DayOfMonth, current, -i)
+	}
+
 	return resp.CustomResponse(http.StatusOK, "success", result)
 }
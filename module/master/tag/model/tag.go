@@ -0,0 +1,35 @@
+package model
+
+type Tag struct {
+	ID     uint   `db:"id" json:"id"`
+	Name   string `db:"name" json:"name"`
+	UserId uint   `db:"user_id" json:"-"`
+}
+
+// GetTag counts the incomes and expenses carrying the tag
+type GetTag struct {
+	ID    uint   `db:"id" json:"id"`
+	Name  string `db:"name" json:"name"`
+	Total uint   `db:"total" json:"total"`
+}
+
+// TransactionTag is a tag of an income or expense, keyed by the transaction
+type TransactionTag struct {
+	TransactionId uint   `db:"transaction_id"`
+	ID            uint   `db:"id"`
+	Name          string `db:"name"`
+}
+
+type AddRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+type UpdateRequest struct {
+	ID   uint
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+type AutocompleteRequest struct {
+	Keyword string `query:"keyword" validate:"max=50"`
+	Limit   uint   `query:"limit" validate:"omitempty,max=50"`
+}
@@ -0,0 +1,251 @@
+package tag
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/master/tag/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Insert(data *model.Tag, tx *sqlx.Tx) error
+	List(userId uint, db *sqlx.DB) (result []model.GetTag, err error)
+	Autocomplete(userId uint, keyword string, limit uint, db *sqlx.DB) (result []model.Tag, err error)
+	GetById(userId, id uint, db *sqlx.DB) (result model.Tag, err error)
+	GetByName(userId uint, name string, db *sqlx.DB) (result model.Tag, err error)
+	Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error
+	Delete(userId, id uint, tx *sqlx.Tx) error
+	Ensure(userId uint, names []string, tx *sqlx.Tx) (result []uint, err error)
+}
+
+type repository struct{}
+
+func NewRepository() Repository {
+	return &repository{}
+}
+
+func (r *repository) Insert(data *model.Tag, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("tag").Rows(*data)
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	res, err := tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	data.ID = uint(id)
+
+	return nil
+}
+
+// List returns the tags of the user with how many incomes and expenses
+// carry each of them
+func (r *repository) List(userId uint, db *sqlx.DB) (result []model.GetTag, err error) {
+	dialect := libs.GetDialect()
+
+	incomeTotal := dialect.From("income_tag").
+		Select(goqu.COUNT("*")).
+		Where(goqu.I("income_tag.tag_id").Eq(goqu.I("t.id")))
+
+	expenseTotal := dialect.From("expense_tag").
+		Select(goqu.COUNT("*")).
+		Where(goqu.I("expense_tag.tag_id").Eq(goqu.I("t.id")))
+
+	dataset := dialect.From(goqu.T("tag").As("t")).
+		Select(
+			goqu.I("t.id"),
+			goqu.I("t.name"),
+			goqu.L("(?) + (?)", incomeTotal, expenseTotal).As("total"),
+		).
+		Where(goqu.I("t.user_id").Eq(userId)).
+		Order(goqu.I("t.name").Asc())
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.GetTag, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+// Autocomplete returns the tags whose name starts with the keyword
+func (r *repository) Autocomplete(userId uint, keyword string, limit uint, db *sqlx.DB) (result []model.Tag, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("tag").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("name").ILike(keyword+"%"),
+		).
+		Order(goqu.I("name").Asc()).
+		Limit(limit)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	result = make([]model.Tag, 0)
+	err = db.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) GetById(userId, id uint, db *sqlx.DB) (result model.Tag, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("tag").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) GetByName(userId uint, name string, db *sqlx.DB) (result model.Tag, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("tag").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("name").Eq(name),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) Update(userId, id uint, data map[string]any, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Update("tag").
+		Set(data).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) Delete(userId, id uint, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Delete("tag").
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("id").Eq(id),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete: %w", err)
+	}
+
+	return nil
+}
+
+// Ensure returns the ids of the named tags, creating the ones that do not
+// exist yet
+func (r *repository) Ensure(userId uint, names []string, tx *sqlx.Tx) (result []uint, err error) {
+	result = make([]uint, 0, len(names))
+	if len(names) == 0 {
+		return
+	}
+
+	dialect := libs.GetDialect()
+
+	rows := make([]model.Tag, len(names))
+	for i, name := range names {
+		rows[i] = model.Tag{Name: name, UserId: userId}
+	}
+
+	insertDataset := dialect.Insert("tag").
+		Rows(rows).
+		OnConflict(goqu.DoNothing())
+
+	sql, val, err := insertDataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	dataset := dialect.From("tag").
+		Select(goqu.I("id")).
+		Where(
+			goqu.I("user_id").Eq(userId),
+			goqu.I("name").In(names),
+		)
+
+	sql, val, err = dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = tx.Select(&result, sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
@@ -0,0 +1,211 @@
+package tag
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/tag/model"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultAutocompleteLimit = 10
+
+type Usecase interface {
+	Add(user *userModel.User, req *model.AddRequest) (resp common.Response)
+	List(user *userModel.User) (resp common.Response)
+	Autocomplete(user *userModel.User, req *model.AutocompleteRequest) (resp common.Response)
+	Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response)
+	Delete(user *userModel.User, id uint) (resp common.Response)
+}
+
+type usecase struct {
+	log  *logrus.Logger
+	repo Repository
+}
+
+func NewUsecase(log *logrus.Logger, repo Repository) Usecase {
+	return &usecase{
+		log,
+		repo,
+	}
+}
+
+func (u *usecase) Add(user *userModel.User, req *model.AddRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return resp.CustomResponse(http.StatusBadRequest, "name must not be blank", nil)
+	}
+
+	_, err := u.repo.GetByName(user.ID, name, db)
+	if err == nil {
+		return resp.CustomResponse(http.StatusBadRequest, "tag already exists", nil)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		u.log.Errorf("repo.GetByName: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	data := model.Tag{
+		Name:   name,
+		UserId: user.ID,
+	}
+
+	err = u.repo.Insert(&data, tx)
+	if err != nil {
+		u.log.Errorf("failed insert tag: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusCreated, "success", nil)
+}
+
+func (u *usecase) List(user *userModel.User) (resp common.Response) {
+	db := config.GetDatabase()
+
+	result, err := u.repo.List(user.ID, db)
+	if err != nil {
+		u.log.Errorf("repo.List: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+// Autocomplete suggests the tags starting with what was typed so far
+func (u *usecase) Autocomplete(user *userModel.User, req *model.AutocompleteRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultAutocompleteLimit
+	}
+
+	result, err := u.repo.Autocomplete(user.ID, strings.TrimSpace(req.Keyword), limit, db)
+	if err != nil {
+		u.log.Errorf("repo.Autocomplete: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+func (u *usecase) Update(user *userModel.User, req *model.UpdateRequest) (resp common.Response) {
+	db := config.GetDatabase()
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return resp.CustomResponse(http.StatusBadRequest, "name must not be blank", nil)
+	}
+
+	_, err := u.repo.GetById(user.ID, req.ID, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "tag not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetById: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	existing, err := u.repo.GetByName(user.ID, name, db)
+	if err == nil && existing.ID != req.ID {
+		return resp.CustomResponse(http.StatusBadRequest, "tag already exists", nil)
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		u.log.Errorf("repo.GetByName: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	err = u.repo.Update(user.ID, req.ID, map[string]any{"name": name}, tx)
+	if err != nil {
+		u.log.Errorf("failed update tag: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+// Delete removes the tag from every transaction carrying it
+func (u *usecase) Delete(user *userModel.User, id uint) (resp common.Response) {
+	db := config.GetDatabase()
+
+	_, err := u.repo.GetById(user.ID, id, db)
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		return resp.CustomResponse(http.StatusNotFound, "tag not found", nil)
+	} else if err != nil {
+		u.log.Errorf("repo.GetById: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		u.log.Errorf("error begin tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+	defer tx.Rollback()
+
+	err = u.repo.Delete(user.ID, id, tx)
+	if err != nil {
+		u.log.Errorf("failed delete tag: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	if err := tx.Commit(); err != nil {
+		u.log.Errorf("failed commit tx: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", nil)
+}
+
+// NormalizeNames trims the tag names given with a transaction and drops the
+// blank and repeated ones, names differing only in case are the same tag
+func NormalizeNames(names []string) []string {
+	seen := make(map[string]struct{}, len(names))
+
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if name == "" {
+			continue
+		}
+
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		result = append(result, name)
+	}
+
+	return result
+}
@@ -0,0 +1,26 @@
+package model
+
+import "github.com/doug-martin/goqu/v9/exp"
+
+// Target is a table holding encrypted columns
+type Target struct {
+	Table   string
+	Columns []string
+	// Owner selects the id of the user whose key encrypts the row, the table
+	// is aliased as "t"
+	Owner exp.Expression
+}
+
+type Row struct {
+	ID     uint
+	UserId uint
+	Values []string
+}
+
+type Progress struct {
+	TableName   string `db:"table_name"`
+	FromVersion int    `db:"from_version"`
+	ToVersion   int    `db:"to_version"`
+	LastId      uint   `db:"last_id"`
+	Done        bool   `db:"done"`
+}
@@ -0,0 +1,346 @@
+package reencrypt
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/reencrypt/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// targets lists every encrypted column. Snapshot details have no user_id of
+// their own, so the owner comes from their snapshot. Nullable columns such as
+// the value bounds of rules are read as empty strings and skipped.
+var targets = []model.Target{
+	{Table: "income", Columns: []string{"value"}, Owner: goqu.I("t.user_id")},
+	{Table: "expense", Columns: []string{"value"}, Owner: goqu.I("t.user_id")},
+	{Table: "asset", Columns: []string{"value", "amount"}, Owner: goqu.I("t.user_id")},
+	{Table: "liability", Columns: []string{"value"}, Owner: goqu.I("t.user_id")},
+	{Table: "recurring", Columns: []string{"value"}, Owner: goqu.I("t.user_id")},
+	{Table: "transfer", Columns: []string{"value"}, Owner: goqu.I("t.user_id")},
+	{Table: "account", Columns: []string{"opening_balance"}, Owner: goqu.I("t.user_id")},
+	{Table: "budget", Columns: []string{"value"}, Owner: goqu.I("t.user_id")},
+	{Table: "cashflow_aggregate", Columns: []string{"value"}, Owner: goqu.I("t.user_id")},
+	{Table: "category_rule", Columns: []string{"min_value", "max_value"}, Owner: goqu.I("t.user_id")},
+	{Table: "balance_sheet_snapshot", Columns: []string{"total_asset", "total_liability", "net_worth"}, Owner: goqu.I("t.user_id")},
+	{
+		Table:   "balance_sheet_snapshot_detail",
+		Columns: []string{"value"},
+		Owner: libs.GetDialect().From(goqu.T("balance_sheet_snapshot").As("s")).
+			Select(goqu.I("s.user_id")).
+			Where(goqu.I("s.id").Eq(goqu.I("t.snapshot_id"))),
+	},
+}
+
+type stats struct {
+	scanned     int
+	reencrypted int
+	current     int
+	other       int
+	failed      int
+	changed     int
+	// failedIds are the rows with a value that failed, in scan order
+	failedIds []uint
+}
+
+type command struct {
+	log         *logrus.Logger
+	repo        Repository
+	fromVersion int
+	toVersion   int
+	batchSize   uint
+	dryRun      bool
+	restart     bool
+}
+
+// Run re-encrypts every value written with one key version using another,
+// e.g. "reencrypt --from-version 0 --to-version 1" moves legacy values onto
+// per-user data keys. Rows are read in batches by id and the last id of each
+// committed batch is saved, so a stopped run resumes where it left off.
+// Values not at --from-version are left alone, which makes rerunning safe.
+// A table with rows that failed is not marked done, the saved progress stops
+// before the first of them so the next run retries them.
+func Run(args []string) error {
+	flags := flag.NewFlagSet("reencrypt", flag.ContinueOnError)
+	fromVersion := flags.Int("from-version", -1, "key version the values are encrypted with now")
+	toVersion := flags.Int("to-version", -1, "key version to encrypt the values with")
+	batchSize := flags.Uint("batch-size", 500, "rows read and written per transaction")
+	dryRun := flags.Bool("dry-run", false, "decrypt and count without writing anything")
+	restart := flags.Bool("restart", false, "ignore saved progress and scan every row again")
+	table := flags.String("table", "", "only re-encrypt this table")
+
+	if err := flags.Parse(args); err != nil && errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if *fromVersion < 0 || *toVersion < 0 {
+		return errors.New("--from-version and --to-version are required")
+	}
+
+	if *fromVersion == *toVersion {
+		return errors.New("--from-version and --to-version must differ")
+	}
+
+	if *batchSize == 0 {
+		return errors.New("--batch-size must be greater than 0")
+	}
+
+	log := config.GetLogger()
+	// progress is for whoever runs the command, not only the log file
+	log.SetOutput(io.MultiWriter(os.Stdout, log.Out))
+
+	if current := libs.CurrentKeyVersion(); current != *toVersion {
+		log.Warnf("new values are still written with key version %d, set secret.dataKeyVersion to %d or they will need another run", current, *toVersion)
+	}
+
+	cmd := &command{
+		log:         log,
+		repo:        NewRepository(),
+		fromVersion: *fromVersion,
+		toVersion:   *toVersion,
+		batchSize:   *batchSize,
+		dryRun:      *dryRun,
+		restart:     *restart,
+	}
+
+	var found bool
+	var failedTables []string
+	for i := range targets {
+		if *table != "" && targets[i].Table != *table {
+			continue
+		}
+
+		found = true
+		failed, err := cmd.run(&targets[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", targets[i].Table, err)
+		}
+
+		if failed {
+			failedTables = append(failedTables, targets[i].Table)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("unknown table %q", *table)
+	}
+
+	if len(failedTables) > 0 {
+		return fmt.Errorf("some values failed in %s, run again to retry them", strings.Join(failedTables, ", "))
+	}
+
+	return nil
+}
+
+// run re-encrypts one table and reports whether any of its values failed
+func (c *command) run(target *model.Target) (bool, error) {
+	db := config.GetDatabase()
+
+	progress, err := c.repo.GetProgress(target.Table, c.fromVersion, c.toVersion, db)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("repo.GetProgress: %w", err)
+	}
+
+	if c.restart || c.dryRun || errors.Is(err, sql.ErrNoRows) {
+		progress = model.Progress{
+			TableName:   target.Table,
+			FromVersion: c.fromVersion,
+			ToVersion:   c.toVersion,
+		}
+	}
+
+	if progress.Done {
+		c.log.Infof("%s: already done, use --restart to scan it again", target.Table)
+		return false, nil
+	}
+
+	if progress.LastId > 0 {
+		c.log.Infof("%s: resuming after id %d", target.Table, progress.LastId)
+	}
+
+	var total stats
+	for {
+		rows, err := c.repo.ListRow(target, progress.LastId, c.batchSize, db)
+		if err != nil {
+			return false, fmt.Errorf("repo.ListRow: %w", err)
+		}
+
+		if len(rows) == 0 {
+			break
+		}
+
+		progress.LastId = rows[len(rows)-1].ID
+		progress.Done = uint(len(rows)) < c.batchSize
+
+		if err := c.runBatch(target, rows, &progress, &total); err != nil {
+			return false, err
+		}
+
+		c.log.Infof("%s: up to id %d, %d row(s) scanned, %d value(s) re-encrypted", target.Table, progress.LastId, total.scanned, total.reencrypted)
+
+		if progress.Done {
+			break
+		}
+	}
+
+	if !c.dryRun && !progress.Done && len(total.failedIds) == 0 {
+		// the last batch was full, nothing is left after it
+		if err := c.saveProgress(&model.Progress{
+			TableName:   target.Table,
+			FromVersion: c.fromVersion,
+			ToVersion:   c.toVersion,
+			LastId:      progress.LastId,
+			Done:        true,
+		}); err != nil {
+			return false, err
+		}
+	}
+
+	verb := "re-encrypted"
+	if c.dryRun {
+		verb = "would be re-encrypted"
+	}
+
+	c.log.Infof(
+		"%s: done, %d row(s) scanned, %d value(s) %s, %d already at version %d, %d at another version, %d failed, %d changed while running",
+		target.Table, total.scanned, total.reencrypted, verb, total.current, c.toVersion, total.other, total.failed, total.changed,
+	)
+
+	if len(total.failedIds) > 0 {
+		c.log.Errorf("%s: failed ids %v", target.Table, total.failedIds)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (c *command) runBatch(target *model.Target, rows []model.Row, progress *model.Progress, total *stats) error {
+	var tx *sqlx.Tx
+	if !c.dryRun {
+		var err error
+		tx, err = config.GetDatabase().Beginx()
+		if err != nil {
+			return fmt.Errorf("error begin tx: %w", err)
+		}
+		defer tx.Rollback()
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		total.scanned++
+
+		failed := total.failed
+		values, changed := c.reencryptRow(target, row, total)
+		if total.failed > failed {
+			total.failedIds = append(total.failedIds, row.ID)
+		}
+		if !changed || c.dryRun {
+			continue
+		}
+
+		updated, err := c.repo.UpdateRow(target, row, values, tx)
+		if err != nil {
+			return fmt.Errorf("repo.UpdateRow: %w", err)
+		}
+
+		if !updated {
+			total.changed++
+		}
+	}
+
+	if c.dryRun {
+		return nil
+	}
+
+	// keep the failed rows ahead of the saved progress
+	saved := *progress
+	if len(total.failedIds) > 0 {
+		saved.LastId = total.failedIds[0] - 1
+		saved.Done = false
+	}
+
+	if err := c.repo.SaveProgress(&saved, tx); err != nil {
+		return fmt.Errorf("repo.SaveProgress: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// reencryptRow returns the new values of a row and whether any changed. A dry
+// run only decrypts, since encrypting would create the users' new data keys.
+func (c *command) reencryptRow(target *model.Target, row *model.Row, total *stats) ([]string, bool) {
+	key := fmt.Sprintf("%d", row.UserId)
+	values := make([]string, len(row.Values))
+	copy(values, row.Values)
+
+	var changed bool
+	for i, value := range row.Values {
+		if value == "" {
+			continue
+		}
+
+		switch libs.CipherVersion(value) {
+		case c.toVersion:
+			total.current++
+			continue
+		case c.fromVersion:
+		default:
+			total.other++
+			continue
+		}
+
+		plain, err := libs.Decrypt(key, value)
+		if err != nil {
+			c.log.Errorf("%s %d: failed decrypt %s: %s", target.Table, row.ID, target.Columns[i], err.Error())
+			total.failed++
+			continue
+		}
+
+		if c.dryRun {
+			total.reencrypted++
+			continue
+		}
+
+		encValue, err := libs.EncryptVersion(key, plain, c.toVersion)
+		if err != nil {
+			c.log.Errorf("%s %d: failed encrypt %s: %s", target.Table, row.ID, target.Columns[i], err.Error())
+			total.failed++
+			continue
+		}
+
+		values[i] = encValue
+		total.reencrypted++
+		changed = true
+	}
+
+	return values, changed
+}
+
+func (c *command) saveProgress(progress *model.Progress) error {
+	tx, err := config.GetDatabase().Beginx()
+	if err != nil {
+		return fmt.Errorf("error begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := c.repo.SaveProgress(progress, tx); err != nil {
+		return fmt.Errorf("repo.SaveProgress: %w", err)
+	}
+
+	return tx.Commit()
+}
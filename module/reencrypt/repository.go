@@ -0,0 +1,159 @@
+package reencrypt
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/reencrypt/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	ListRow(target *model.Target, lastId, limit uint, db *sqlx.DB) (result []model.Row, err error)
+	UpdateRow(target *model.Target, row *model.Row, values []string, tx *sqlx.Tx) (updated bool, err error)
+	GetProgress(table string, fromVersion, toVersion int, db *sqlx.DB) (result model.Progress, err error)
+	SaveProgress(data *model.Progress, tx *sqlx.Tx) error
+}
+
+type repository struct{}
+
+func NewRepository() Repository {
+	return &repository{}
+}
+
+func (r *repository) ListRow(target *model.Target, lastId, limit uint, db *sqlx.DB) (result []model.Row, err error) {
+	dialect := libs.GetDialect()
+
+	columns := []any{goqu.I("t.id"), goqu.L("?", target.Owner).As("user_id")}
+	for _, column := range target.Columns {
+		columns = append(columns, goqu.COALESCE(goqu.I("t."+column), "").As(column))
+	}
+
+	dataset := dialect.From(goqu.T(target.Table).As("t")).
+		Select(columns...).
+		Where(goqu.I("t.id").Gt(lastId)).
+		Order(goqu.I("t.id").Asc()).
+		Limit(limit)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	rows, err := db.Queryx(sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	result = make([]model.Row, 0)
+	for rows.Next() {
+		row := model.Row{Values: make([]string, len(target.Columns))}
+
+		dest := []any{&row.ID, &row.UserId}
+		for i := range row.Values {
+			dest = append(dest, &row.Values[i])
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// UpdateRow writes the changed values only when the row still holds the
+// values it was read with, so a value changed by the app meanwhile is left
+// alone
+func (r *repository) UpdateRow(target *model.Target, row *model.Row, values []string, tx *sqlx.Tx) (updated bool, err error) {
+	dialect := libs.GetDialect()
+
+	record := goqu.Record{}
+	where := []goqu.Expression{goqu.I("id").Eq(row.ID)}
+	for i, column := range target.Columns {
+		if values[i] == row.Values[i] {
+			continue
+		}
+
+		record[column] = values[i]
+		where = append(where, goqu.I(column).Eq(row.Values[i]))
+	}
+
+	dataset := dialect.Update(target.Table).
+		Set(record).
+		Where(where...)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return false, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	res, err := tx.Exec(sql, val...)
+	if err != nil {
+		return false, fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *repository) GetProgress(table string, fromVersion, toVersion int, db *sqlx.DB) (result model.Progress, err error) {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.From("reencrypt_progress").
+		Select(
+			goqu.I("table_name"),
+			goqu.I("from_version"),
+			goqu.I("to_version"),
+			goqu.I("last_id"),
+			goqu.I("done"),
+		).
+		Where(
+			goqu.I("table_name").Eq(table),
+			goqu.I("from_version").Eq(fromVersion),
+			goqu.I("to_version").Eq(toVersion),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return result, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	err = db.Get(&result, sql, val...)
+	if err != nil {
+		return result, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) SaveProgress(data *model.Progress, tx *sqlx.Tx) error {
+	dialect := libs.GetDialect()
+
+	dataset := dialect.Insert("reencrypt_progress").
+		Rows(*data).
+		OnConflict(goqu.DoUpdate("table_name", goqu.Record{
+			"last_id": goqu.L("VALUES(last_id)"),
+			"done":    goqu.L("VALUES(done)"),
+		}))
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	_, err = tx.Exec(sql, val...)
+	if err != nil {
+		return fmt.Errorf("failed to execute insert: %w", err)
+	}
+
+	return nil
+}
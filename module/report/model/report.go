@@ -0,0 +1,102 @@
+package model
+
+import (
+	"github.com/fazriegi/money_management-be/libs"
+	periodModel "github.com/fazriegi/money_management-be/module/master/period/model"
+)
+
+type GetTransaction struct {
+	ID         uint        `db:"id"`
+	CategoryId uint        `db:"category_id"`
+	Category   string      `db:"category"`
+	Date       interface{} `db:"date"`
+	Value      string      `db:"value"`
+	Type       string      `db:"type"`
+	Currency   string      `db:"currency"`
+}
+
+type SummaryRequest struct {
+	Period string `query:"period"`
+	Rollup bool   `query:"rollup"`
+}
+
+type CategoryTotal struct {
+	CategoryId uint       `json:"category_id"`
+	Category   string     `json:"category"`
+	ParentId   *uint      `json:"parent_id"`
+	Total      libs.Money `json:"total"`
+	Percentage float64    `json:"percentage"`
+}
+
+// TagTotal sums the incomes and expenses carrying the tag, a transaction with
+// several tags counts toward each of them
+type TagTotal struct {
+	TagId   uint       `json:"tag_id"`
+	Tag     string     `json:"tag"`
+	Income  libs.Money `json:"income"`
+	Expense libs.Money `json:"expense"`
+	Net     libs.Money `json:"net"`
+}
+
+type Summary struct {
+	Period       periodModel.PeriodRange `json:"period"`
+	TotalIncome  libs.Money              `json:"total_income"`
+	TotalExpense libs.Money              `json:"total_expense"`
+	NetCashflow  libs.Money              `json:"net_cashflow"`
+	SavingsRate  float64                 `json:"savings_rate"`
+	Income       []CategoryTotal         `json:"income"`
+	Expense      []CategoryTotal         `json:"expense"`
+	Tags         []TagTotal              `json:"tags"`
+	Currency     string                  `json:"currency"`
+}
+
+// Category is an income or expense category reduced to what reports need
+type Category struct {
+	ID       uint
+	Name     string
+	ParentId *uint
+}
+
+const (
+	GroupByType     = "type"
+	GroupByCategory = "category"
+)
+
+type TrendRequest struct {
+	Months  uint   `query:"months" validate:"omitempty,max=120"`
+	GroupBy string `query:"group_by" validate:"omitempty,oneof=category type"`
+}
+
+// Delta compares a total with an earlier one, Percentage is empty when the
+// earlier total is zero
+type Delta struct {
+	Value      libs.Money `json:"value"`
+	Percentage *float64   `json:"percentage"`
+}
+
+type TrendValue struct {
+	Total          libs.Money `json:"total"`
+	PreviousPeriod Delta      `json:"previous_period"`
+	LastYear       Delta      `json:"last_year"`
+}
+
+type TrendCategory struct {
+	Type       string `json:"type"`
+	CategoryId uint   `json:"category_id"`
+	Category   string `json:"category"`
+	TrendValue
+}
+
+type TrendBucket struct {
+	Period     periodModel.PeriodRange `json:"period"`
+	Income     TrendValue              `json:"income"`
+	Expense    TrendValue              `json:"expense"`
+	Net        TrendValue              `json:"net"`
+	Categories []TrendCategory         `json:"categories,omitempty"`
+}
+
+type Trend struct {
+	GroupBy  string        `json:"group_by"`
+	Data     []TrendBucket `json:"data"`
+	Currency string        `json:"currency"`
+}
@@ -0,0 +1,69 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/expense"
+	"github.com/fazriegi/money_management-be/module/cashflow/income"
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/report/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	ListTransaction(req *cashflowModel.ListFilter, db *sqlx.DB) (result []model.GetTransaction, err error)
+}
+
+type repository struct {
+	incomeRepo  income.Repository
+	expenseRepo expense.Repository
+}
+
+func NewRepository(incomeRepo income.Repository, expenseRepo expense.Repository) Repository {
+	return &repository{
+		incomeRepo,
+		expenseRepo,
+	}
+}
+
+// ListTransaction returns the incomes and expenses matching the filter from
+// the same union the cashflow listing uses, transfers are left out
+func (r *repository) ListTransaction(req *cashflowModel.ListFilter, db *sqlx.DB) (result []model.GetTransaction, err error) {
+	dialect := libs.GetDialect()
+
+	incomeDataset := r.incomeRepo.CreateListQuery(req)
+	expenseDataset := r.expenseRepo.CreateListQuery(req)
+
+	dataset := dialect.
+		From(incomeDataset.Union(expenseDataset).As("obj")).
+		Select(
+			goqu.I("id"),
+			goqu.I("category_id"),
+			goqu.I("category"),
+			goqu.I("date"),
+			goqu.I("value"),
+			goqu.I("type"),
+			goqu.I("currency"),
+		)
+
+	sql, val, err := dataset.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+
+	row, err := db.Queryx(sql, val...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer row.Close()
+
+	result = make([]model.GetTransaction, 0)
+	err = libs.ScanRowsIntoStructs(row, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan rows into structs: %w", err)
+	}
+
+	return
+}
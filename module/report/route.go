@@ -0,0 +1,27 @@
+package report
+
+import (
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/middleware"
+	"github.com/fazriegi/money_management-be/module/cashflow/expense"
+	"github.com/fazriegi/money_management-be/module/cashflow/income"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	"github.com/gofiber/fiber/v2"
+)
+
+func NewRoute(app *fiber.App, jwt *libs.JWT) {
+	log := config.GetLogger()
+	incomeRepo := income.NewRepository()
+	expenseRepo := expense.NewRepository()
+	periodRepo := period.NewRepository()
+	currencyRepo := currency.NewRepository()
+	repo := NewRepository(incomeRepo, expenseRepo)
+	usecase := NewUsecase(log, repo, incomeRepo, expenseRepo, periodRepo, currencyRepo)
+	controller := NewController(log, usecase)
+
+	route := app.Group("/report")
+	route.Get("/summary", middleware.Authentication(jwt), controller.Summary)
+	route.Get("/trend", middleware.Authentication(jwt), controller.Trend)
+}
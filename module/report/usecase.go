@@ -0,0 +1,541 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/fazriegi/money_management-be/config"
+	"github.com/fazriegi/money_management-be/constant"
+	"github.com/fazriegi/money_management-be/libs"
+	"github.com/fazriegi/money_management-be/module/cashflow/expense"
+	expenseModel "github.com/fazriegi/money_management-be/module/cashflow/expense/model"
+	"github.com/fazriegi/money_management-be/module/cashflow/income"
+	incomeModel "github.com/fazriegi/money_management-be/module/cashflow/income/model"
+	cashflowModel "github.com/fazriegi/money_management-be/module/cashflow/model"
+	"github.com/fazriegi/money_management-be/module/common"
+	"github.com/fazriegi/money_management-be/module/master/currency"
+	"github.com/fazriegi/money_management-be/module/master/period"
+	tagModel "github.com/fazriegi/money_management-be/module/master/tag/model"
+	userModel "github.com/fazriegi/money_management-be/module/master/user/model"
+	"github.com/fazriegi/money_management-be/module/report/model"
+	"github.com/sirupsen/logrus"
+)
+
+type Usecase interface {
+	Summary(user *userModel.User, req *model.SummaryRequest) (resp common.Response)
+	Trend(user *userModel.User, req *model.TrendRequest) (resp common.Response)
+}
+
+const defaultTrendMonths = 12
+
+type usecase struct {
+	log          *logrus.Logger
+	repo         Repository
+	incomeRepo   income.Repository
+	expenseRepo  expense.Repository
+	periodRepo   period.Repository
+	currencyRepo currency.Repository
+}
+
+func NewUsecase(log *logrus.Logger, repo Repository, incomeRepo income.Repository, expenseRepo expense.Repository, periodRepo period.Repository, currencyRepo currency.Repository) Usecase {
+	return &usecase{
+		log,
+		repo,
+		incomeRepo,
+		expenseRepo,
+		periodRepo,
+		currencyRepo,
+	}
+}
+
+func (u *usecase) Summary(user *userModel.User, req *model.SummaryRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	key := fmt.Sprintf("%d", user.ID)
+
+	if req.Period == "" {
+		req.Period = period.PeriodCurrent
+	}
+
+	periodRange, err := period.ResolveRange(u.periodRepo, user.ID, req.Period, db)
+	if err != nil && errors.Is(err, period.ErrInvalidPeriod) {
+		return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+	} else if err != nil {
+		u.log.Errorf("period.ResolveRange: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	transactions, err := u.repo.ListTransaction(&cashflowModel.ListFilter{
+		UserId:    user.ID,
+		StartDate: periodRange.StartDate.Format(constant.DateFormat),
+		EndDate:   periodRange.EndDate.Format(constant.DateFormat),
+	}, db)
+	if err != nil {
+		u.log.Errorf("repo.ListTransaction: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	incomeCategories, expenseCategories, err := u.listCategories(user.ID)
+	if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	converter, err := currency.NewConverter(user.ID, u.currencyRepo, db)
+	if err != nil {
+		u.log.Errorf("currency.NewConverter: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	result := model.Summary{
+		Period:   periodRange,
+		Currency: converter.Base(),
+	}
+
+	incomeTags, expenseTags, err := u.listTags(transactions)
+	if err != nil {
+		u.log.Errorf("%s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	incomeByCategory := make(map[uint]libs.Money)
+	expenseByCategory := make(map[uint]libs.Money)
+	byTag := make(map[uint]*model.TagTotal)
+	for _, data := range transactions {
+		decValue, err := libs.Decrypt(key, data.Value)
+		if err != nil {
+			u.log.Errorf("error decrypting value: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		value, err := libs.ParseMoney(decValue)
+		if err != nil {
+			u.log.Errorf("error parsing string: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		value, err = converter.Convert(value, data.Currency, libs.ParseDate(data.Date))
+		if err != nil && errors.Is(err, currency.ErrRateNotFound) {
+			return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+		} else if err != nil {
+			u.log.Errorf("converter.Convert: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		switch data.Type {
+		case cashflowModel.TypeIncome:
+			incomeByCategory[data.CategoryId] = incomeByCategory[data.CategoryId].Add(value)
+			result.TotalIncome = result.TotalIncome.Add(value)
+
+			for _, t := range incomeTags[data.ID] {
+				total := tagTotal(byTag, t)
+				total.Income = total.Income.Add(value)
+			}
+		case cashflowModel.TypeExpense:
+			expenseByCategory[data.CategoryId] = expenseByCategory[data.CategoryId].Add(value)
+			result.TotalExpense = result.TotalExpense.Add(value)
+
+			for _, t := range expenseTags[data.ID] {
+				total := tagTotal(byTag, t)
+				total.Expense = total.Expense.Add(value)
+			}
+		}
+	}
+
+	result.NetCashflow = result.TotalIncome.Sub(result.TotalExpense)
+	if result.TotalIncome.Sign() > 0 {
+		result.SavingsRate = percentage(result.NetCashflow, result.TotalIncome)
+	}
+
+	result.Income = categoryTotals(incomeByCategory, incomeCategories, result.TotalIncome, req.Rollup)
+	result.Expense = categoryTotals(expenseByCategory, expenseCategories, result.TotalExpense, req.Rollup)
+	result.Tags = tagTotals(byTag)
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+// Trend returns the totals of the last months periods, oldest first. Every
+// total is compared with the period before it and the same period a year
+// earlier, so a year more than requested is read.
+func (u *usecase) Trend(user *userModel.User, req *model.TrendRequest) (resp common.Response) {
+	db := config.GetDatabase()
+	key := fmt.Sprintf("%d", user.ID)
+
+	months := int(req.Months)
+	if months == 0 {
+		months = defaultTrendMonths
+	}
+
+	if req.GroupBy == "" {
+		req.GroupBy = model.GroupByType
+	}
+
+	userPeriod, err := u.periodRepo.GetPeriod(user.ID, db)
+	if err != nil {
+		u.log.Errorf("periodRepo.GetPeriod: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	current := period.GetRangeOf(userPeriod.DayOfMonth, time.Now())
+	first := period.Shift(userPeriod.DayOfMonth, current, -(months - 1))
+	readFrom := period.Shift(userPeriod.DayOfMonth, first, -12)
+
+	startDate := readFrom.StartDate.Format(constant.DateFormat)
+	endDate := current.EndDate.Format(constant.DateFormat)
+
+	incomes, err := u.incomeRepo.List(&incomeModel.ListRequest{
+		UserId:    user.ID,
+		StartDate: startDate,
+		EndDate:   endDate,
+	}, db)
+	if err != nil {
+		u.log.Errorf("incomeRepo.List: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	expenses, err := u.expenseRepo.List(&expenseModel.ListRequest{
+		UserId:    user.ID,
+		StartDate: startDate,
+		EndDate:   endDate,
+	}, db)
+	if err != nil {
+		u.log.Errorf("expenseRepo.List: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	transactions := make([]model.GetTransaction, 0, len(incomes)+len(expenses))
+	for _, data := range incomes {
+		transactions = append(transactions, model.GetTransaction{
+			ID:         data.ID,
+			CategoryId: data.CategoryId,
+			Category:   data.Category,
+			Date:       data.Date,
+			Value:      data.Value,
+			Type:       cashflowModel.TypeIncome,
+			Currency:   data.Currency,
+		})
+	}
+
+	for _, data := range expenses {
+		transactions = append(transactions, model.GetTransaction{
+			ID:         data.ID,
+			CategoryId: data.CategoryId,
+			Category:   data.Category,
+			Date:       data.Date,
+			Value:      data.Value,
+			Type:       cashflowModel.TypeExpense,
+			Currency:   data.Currency,
+		})
+	}
+
+	converter, err := currency.NewConverter(user.ID, u.currencyRepo, db)
+	if err != nil {
+		u.log.Errorf("currency.NewConverter: %s", err.Error())
+		return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+	}
+
+	type categoryKey struct {
+		categoryType string
+		categoryId   uint
+	}
+
+	// totals per period, keyed by the period name
+	incomeByPeriod := make(map[string]libs.Money)
+	expenseByPeriod := make(map[string]libs.Money)
+	categoryByPeriod := make(map[string]map[categoryKey]libs.Money)
+	categoryNames := make(map[categoryKey]string)
+	for _, data := range transactions {
+		decValue, err := libs.Decrypt(key, data.Value)
+		if err != nil {
+			u.log.Errorf("error decrypting value: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		value, err := libs.ParseMoney(decValue)
+		if err != nil {
+			u.log.Errorf("error parsing string: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		date := libs.ParseDate(data.Date)
+		value, err = converter.Convert(value, data.Currency, date)
+		if err != nil && errors.Is(err, currency.ErrRateNotFound) {
+			return resp.CustomResponse(http.StatusBadRequest, err.Error(), nil)
+		} else if err != nil {
+			u.log.Errorf("converter.Convert: %s", err.Error())
+			return resp.CustomResponse(http.StatusInternalServerError, constant.ServerErr, nil)
+		}
+
+		periodName := period.GetRangeOf(userPeriod.DayOfMonth, date).Period
+		if data.Type == cashflowModel.TypeIncome {
+			incomeByPeriod[periodName] = incomeByPeriod[periodName].Add(value)
+		} else {
+			expenseByPeriod[periodName] = expenseByPeriod[periodName].Add(value)
+		}
+
+		k := categoryKey{data.Type, data.CategoryId}
+		if categoryByPeriod[periodName] == nil {
+			categoryByPeriod[periodName] = make(map[categoryKey]libs.Money)
+		}
+		categoryByPeriod[periodName][k] = categoryByPeriod[periodName][k].Add(value)
+		categoryNames[k] = data.Category
+	}
+
+	netByPeriod := func(periodName string) libs.Money {
+		return incomeByPeriod[periodName].Sub(expenseByPeriod[periodName])
+	}
+
+	result := model.Trend{
+		GroupBy:  req.GroupBy,
+		Currency: converter.Base(),
+		Data:     make([]model.TrendBucket, months),
+	}
+
+	for i := range result.Data {
+		periodRange := period.Shift(userPeriod.DayOfMonth, first, i)
+		previous := period.Shift(userPeriod.DayOfMonth, periodRange, -1).Period
+		lastYear := period.Shift(userPeriod.DayOfMonth, periodRange, -12).Period
+
+		trendValue := func(total func(periodName string) libs.Money) model.TrendValue {
+			return newTrendValue(total(periodRange.Period), total(previous), total(lastYear))
+		}
+
+		bucket := model.TrendBucket{
+			Period:  periodRange,
+			Income:  trendValue(func(periodName string) libs.Money { return incomeByPeriod[periodName] }),
+			Expense: trendValue(func(periodName string) libs.Money { return expenseByPeriod[periodName] }),
+			Net:     trendValue(netByPeriod),
+		}
+
+		if req.GroupBy == model.GroupByCategory {
+			keys := make([]categoryKey, 0)
+			for _, periodName := range []string{periodRange.Period, previous, lastYear} {
+				for k := range categoryByPeriod[periodName] {
+					if !slices.Contains(keys, k) {
+						keys = append(keys, k)
+					}
+				}
+			}
+
+			sort.Slice(keys, func(i, j int) bool {
+				if keys[i].categoryType != keys[j].categoryType {
+					return keys[i].categoryType < keys[j].categoryType
+				}
+
+				return keys[i].categoryId < keys[j].categoryId
+			})
+
+			bucket.Categories = make([]model.TrendCategory, len(keys))
+			for j, k := range keys {
+				bucket.Categories[j] = model.TrendCategory{
+					Type:       k.categoryType,
+					CategoryId: k.categoryId,
+					Category:   categoryNames[k],
+					TrendValue: trendValue(func(periodName string) libs.Money { return categoryByPeriod[periodName][k] }),
+				}
+			}
+		}
+
+		result.Data[i] = bucket
+	}
+
+	return resp.CustomResponse(http.StatusOK, "success", result)
+}
+
+func (u *usecase) listCategories(userId uint) (incomeCategories, expenseCategories []model.Category, err error) {
+	db := config.GetDatabase()
+
+	incomeData, err := u.incomeRepo.ListCategory(userId, db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("incomeRepo.ListCategory: %w", err)
+	}
+
+	expenseData, err := u.expenseRepo.ListCategory(userId, db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("expenseRepo.ListCategory: %w", err)
+	}
+
+	incomeCategories = make([]model.Category, len(incomeData))
+	for i, category := range incomeData {
+		incomeCategories[i] = model.Category{ID: category.ID, Name: category.Name, ParentId: category.ParentId}
+	}
+
+	expenseCategories = make([]model.Category, len(expenseData))
+	for i, category := range expenseData {
+		expenseCategories[i] = model.Category{ID: category.ID, Name: category.Name, ParentId: category.ParentId}
+	}
+
+	return
+}
+
+// listTags returns the tags of the transactions grouped by income and expense id
+func (u *usecase) listTags(transactions []model.GetTransaction) (incomeTags, expenseTags map[uint][]tagModel.TransactionTag, err error) {
+	db := config.GetDatabase()
+
+	var incomeIds, expenseIds []uint
+	for _, data := range transactions {
+		switch data.Type {
+		case cashflowModel.TypeIncome:
+			incomeIds = append(incomeIds, data.ID)
+		case cashflowModel.TypeExpense:
+			expenseIds = append(expenseIds, data.ID)
+		}
+	}
+
+	incomeData, err := u.incomeRepo.ListTag(incomeIds, db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("incomeRepo.ListTag: %w", err)
+	}
+
+	expenseData, err := u.expenseRepo.ListTag(expenseIds, db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("expenseRepo.ListTag: %w", err)
+	}
+
+	incomeTags = make(map[uint][]tagModel.TransactionTag)
+	for _, t := range incomeData {
+		incomeTags[t.TransactionId] = append(incomeTags[t.TransactionId], t)
+	}
+
+	expenseTags = make(map[uint][]tagModel.TransactionTag)
+	for _, t := range expenseData {
+		expenseTags[t.TransactionId] = append(expenseTags[t.TransactionId], t)
+	}
+
+	return
+}
+
+func tagTotal(byTag map[uint]*model.TagTotal, t tagModel.TransactionTag) *model.TagTotal {
+	total, ok := byTag[t.ID]
+	if !ok {
+		total = &model.TagTotal{TagId: t.ID, Tag: t.Name}
+		byTag[t.ID] = total
+	}
+
+	return total
+}
+
+// tagTotals turns the sums per tag into a list sorted by name
+func tagTotals(byTag map[uint]*model.TagTotal) []model.TagTotal {
+	result := make([]model.TagTotal, 0, len(byTag))
+	for _, total := range byTag {
+		total.Net = total.Income.Sub(total.Expense)
+		result = append(result, *total)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Tag != result[j].Tag {
+			return result[i].Tag < result[j].Tag
+		}
+
+		return result[i].TagId < result[j].TagId
+	})
+
+	return result
+}
+
+// categoryTotals turns the sums per category into a list sorted by the
+// largest total. With rollup every amount is counted toward the top level
+// category it belongs to.
+func categoryTotals(byCategory map[uint]libs.Money, categories []model.Category, total libs.Money, rollup bool) []model.CategoryTotal {
+	categoryById := make(map[uint]model.Category, len(categories))
+	for _, category := range categories {
+		categoryById[category.ID] = category
+	}
+
+	if rollup {
+		rolled := make(map[uint]libs.Money, len(byCategory))
+		for categoryId, value := range byCategory {
+			rootId := rootCategory(categoryId, categoryById)
+			rolled[rootId] = rolled[rootId].Add(value)
+		}
+
+		byCategory = rolled
+	}
+
+	result := make([]model.CategoryTotal, 0, len(byCategory))
+	for categoryId, value := range byCategory {
+		category := categoryById[categoryId]
+
+		var share float64
+		if total.Sign() > 0 {
+			share = percentage(value, total)
+		}
+
+		result = append(result, model.CategoryTotal{
+			CategoryId: categoryId,
+			Category:   category.Name,
+			ParentId:   category.ParentId,
+			Total:      value,
+			Percentage: share,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if cmp := result[i].Total.Cmp(result[j].Total); cmp != 0 {
+			return cmp > 0
+		}
+
+		return result[i].CategoryId < result[j].CategoryId
+	})
+
+	return result
+}
+
+// rootCategory walks up the parents of a category, stopping at a parent the
+// user does not own or at a cycle
+func rootCategory(categoryId uint, categoryById map[uint]model.Category) uint {
+	visited := map[uint]struct{}{categoryId: {}}
+	for {
+		category, ok := categoryById[categoryId]
+		if !ok || category.ParentId == nil {
+			return categoryId
+		}
+
+		if _, ok := categoryById[*category.ParentId]; !ok {
+			return categoryId
+		}
+
+		if _, ok := visited[*category.ParentId]; ok {
+			return categoryId
+		}
+
+		categoryId = *category.ParentId
+		visited[categoryId] = struct{}{}
+	}
+}
+
+func newTrendValue(total, previous, lastYear libs.Money) model.TrendValue {
+	return model.TrendValue{
+		Total:          total,
+		PreviousPeriod: newDelta(total, previous),
+		LastYear:       newDelta(total, lastYear),
+	}
+}
+
+func newDelta(total, earlier libs.Money) model.Delta {
+	result := model.Delta{
+		Value: total.Sub(earlier),
+	}
+
+	if !earlier.IsZero() {
+		base := earlier
+		if base.Sign() < 0 {
+			base = base.Neg()
+		}
+
+		share := percentage(result.Value, base)
+		result.Percentage = &share
+	}
+
+	return result
+}
+
+// percentage returns part as a percentage of whole rounded to two decimals
+func percentage(part, whole libs.Money) float64 {
+	return math.Round(part.Float64()/whole.Float64()*10000) / 100
+}
@@ -4,8 +4,14 @@ import (
 	"github.com/fazriegi/money_management-be/libs"
 	"github.com/fazriegi/money_management-be/module/auth"
 	balancesheet "github.com/fazriegi/money_management-be/module/balance_sheet"
+	"github.com/fazriegi/money_management-be/module/budget"
 	"github.com/fazriegi/money_management-be/module/cashflow"
+	"github.com/fazriegi/money_management-be/module/importer"
+	"github.com/fazriegi/money_management-be/module/master/account"
+	"github.com/fazriegi/money_management-be/module/master/currency"
 	"github.com/fazriegi/money_management-be/module/master/period"
+	"github.com/fazriegi/money_management-be/module/master/tag"
+	"github.com/fazriegi/money_management-be/module/report"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -13,5 +19,11 @@ func NewRoute(app *fiber.App, jwt *libs.JWT) {
 	auth.NewRoute(app, jwt)
 	cashflow.NewRoute(app, jwt)
 	period.NewRoute(app, jwt)
+	account.NewRoute(app, jwt)
+	currency.NewRoute(app, jwt)
+	tag.NewRoute(app, jwt)
 	balancesheet.NewRoute(app, jwt)
+	budget.NewRoute(app, jwt)
+	report.NewRoute(app, jwt)
+	importer.NewRoute(app, jwt)
 }